@@ -0,0 +1,174 @@
+package llm
+
+import (
+	"errors"
+	"io"
+	"sort"
+	"strings"
+)
+
+// StreamAccumulator merges streamed chunks into a complete ChatResponse. Text content and
+// Reasoning deltas are concatenated per choice; tool-call deltas are merged by ToolCall.Index,
+// concatenating Function.Arguments. The last FinishReason and Usage win.
+// The zero value is ready to use.
+type StreamAccumulator struct {
+	resp    ChatResponse
+	choices map[int]*accChoice
+}
+
+type accChoice struct {
+	role         string
+	content      strings.Builder
+	reasoning    strings.Builder
+	toolCalls    []ToolCall
+	toolIndex    map[int]int // ToolCall.Index -> position in toolCalls
+	finishReason string
+	err          *ChoiceError
+}
+
+// Add merges chunk into the accumulated response. Nil chunks are ignored.
+func (a *StreamAccumulator) Add(chunk *StreamChunk) {
+	a.add(chunk, nil)
+}
+
+// add merges chunk and, if onToolCall is set, reports each tool-call delta of choice 0 with
+// the position of the call it belongs to and whether it started that call.
+func (a *StreamAccumulator) add(chunk *StreamChunk, onToolCall func(pos int, started bool, delta ToolCall)) {
+	if chunk == nil {
+		return
+	}
+	if a.resp.ID == "" {
+		a.resp.ID = chunk.ID
+	}
+	if a.resp.Model == "" {
+		a.resp.Model = chunk.Model
+	}
+	if a.resp.Created == 0 {
+		a.resp.Created = chunk.Created
+	}
+	if chunk.Usage != nil {
+		a.resp.Usage = chunk.Usage
+	}
+	if chunk.Fallback != nil {
+		a.resp.Fallback = chunk.Fallback
+	}
+	if a.choices == nil {
+		a.choices = make(map[int]*accChoice)
+	}
+	for _, ch := range chunk.Choices {
+		c := a.choices[ch.Index]
+		if c == nil {
+			c = &accChoice{toolIndex: make(map[int]int)}
+			a.choices[ch.Index] = c
+		}
+		if ch.FinishReason != "" {
+			c.finishReason = ch.FinishReason
+		}
+		if ch.Error != nil {
+			c.err = ch.Error
+		}
+		// Some backends send a complete Message rather than a Delta.
+		d := ch.Delta
+		if d == nil {
+			d = ch.Message
+		}
+		if d == nil {
+			continue
+		}
+		if d.Role != "" {
+			c.role = d.Role
+		}
+		c.content.WriteString(contentText(d.Content))
+		c.reasoning.WriteString(d.Reasoning)
+		for _, tc := range d.ToolCalls {
+			pos, started := c.addToolCall(tc)
+			if onToolCall != nil && ch.Index == 0 {
+				onToolCall(pos, started, tc)
+			}
+		}
+	}
+}
+
+// addToolCall merges a tool-call delta. Deltas with an Index continue the call at that index;
+// deltas without one start a new call when they carry an ID and otherwise continue the last call.
+// It returns the position of the call in toolCalls and whether the delta started it.
+func (c *accChoice) addToolCall(tc ToolCall) (int, bool) {
+	pos := -1
+	if tc.Index != nil {
+		if p, ok := c.toolIndex[*tc.Index]; ok {
+			pos = p
+		}
+	} else if tc.ID == "" && len(c.toolCalls) > 0 {
+		pos = len(c.toolCalls) - 1
+	}
+	if pos < 0 {
+		idx := len(c.toolCalls)
+		if tc.Index != nil {
+			c.toolIndex[*tc.Index] = idx
+		}
+		tc.Index = nil
+		c.toolCalls = append(c.toolCalls, tc)
+		return idx, true
+	}
+	cur := &c.toolCalls[pos]
+	if tc.ID != "" {
+		cur.ID = tc.ID
+	}
+	if tc.Type != "" {
+		cur.Type = tc.Type
+	}
+	if tc.Function.Name != "" {
+		cur.Function.Name = tc.Function.Name
+	}
+	cur.Function.Arguments += tc.Function.Arguments
+	return pos, false
+}
+
+// Response returns the response accumulated so far. It may be called repeatedly.
+func (a *StreamAccumulator) Response() *ChatResponse {
+	resp := a.resp
+	resp.Object = "chat.completion"
+	indexes := make([]int, 0, len(a.choices))
+	for i := range a.choices {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	resp.Choices = make([]Choice, 0, len(indexes))
+	for _, i := range indexes {
+		c := a.choices[i]
+		msg := &Message{Role: c.role, Reasoning: c.reasoning.String()}
+		if msg.Role == "" {
+			msg.Role = "assistant"
+		}
+		if c.content.Len() > 0 {
+			msg.Content = c.content.String()
+		}
+		if len(c.toolCalls) > 0 {
+			msg.ToolCalls = append([]ToolCall(nil), c.toolCalls...)
+			for j := range msg.ToolCalls {
+				if msg.ToolCalls[j].Type == "" {
+					msg.ToolCalls[j].Type = "function"
+				}
+			}
+		}
+		resp.Choices = append(resp.Choices, Choice{Index: i, Message: msg, FinishReason: c.finishReason, Error: c.err})
+	}
+	return &resp
+}
+
+// Accumulate reads r to the end, closes it, and returns the merged ChatResponse.
+// On a stream error it returns the response accumulated so far together with the error.
+func Accumulate(r StreamReader) (*ChatResponse, error) {
+	defer r.Close()
+	var acc StreamAccumulator
+	for {
+		chunk, err := r.Next()
+		acc.Add(chunk)
+		if errors.Is(err, io.EOF) {
+			return acc.Response(), nil
+		}
+		if err != nil {
+			return acc.Response(), err
+		}
+	}
+}
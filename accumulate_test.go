@@ -0,0 +1,83 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func intPtr(i int) *int { return &i }
+
+func TestAccumulate(t *testing.T) {
+	chunks := []*StreamChunk{
+		{ID: "c1", Model: "m", Choices: []Choice{{Delta: &Message{Role: "assistant", Reasoning: "think"}}}},
+		{Choices: []Choice{{Delta: &Message{Content: "Hel", Reasoning: "ing"}}}},
+		{Choices: []Choice{{Delta: &Message{Content: "lo", ToolCalls: []ToolCall{
+			{Index: intPtr(0), ID: "a", Type: "function", Function: FunctionCall{Name: "f", Arguments: `{"x":`}},
+			{Index: intPtr(1), ID: "b", Function: FunctionCall{Name: "g", Arguments: `{}`}},
+		}}}}},
+		{Choices: []Choice{{Delta: &Message{ToolCalls: []ToolCall{{Index: intPtr(0), Function: FunctionCall{Arguments: `1}`}}}}, FinishReason: "tool_calls"}}},
+		{Usage: &Usage{TotalTokens: 9}},
+	}
+	stream := &MockStreamReader{Chunks: chunks}
+	resp, err := Accumulate(stream)
+	if err != nil {
+		t.Fatalf("Accumulate: %v", err)
+	}
+	if !stream.Closed {
+		t.Error("Accumulate should close the stream")
+	}
+	if resp.ID != "c1" || resp.Model != "m" || resp.Usage == nil || resp.Usage.TotalTokens != 9 {
+		t.Errorf("resp = %+v", resp)
+	}
+	ch := resp.Choices[0]
+	if ch.FinishReason != "tool_calls" || ch.Message.Content != "Hello" || ch.Message.Reasoning != "thinking" || ch.Message.Role != "assistant" {
+		t.Errorf("choice = %+v, message = %+v", ch, ch.Message)
+	}
+	calls := ch.Message.ToolCalls
+	if len(calls) != 2 || calls[0].Function.Arguments != `{"x":1}` || calls[1].ID != "b" || calls[1].Type != "function" {
+		t.Errorf("ToolCalls = %+v", calls)
+	}
+}
+
+func TestAccumulate_StreamError(t *testing.T) {
+	boom := errors.New("boom")
+	resp, err := Accumulate(&MockStreamReader{Chunks: []*StreamChunk{textChunk("part")}, Err: boom})
+	if !errors.Is(err, boom) {
+		t.Fatalf("err = %v, want boom", err)
+	}
+	if resp.Choices[0].Message.Content != "part" {
+		t.Errorf("partial content = %v", resp.Choices[0].Message.Content)
+	}
+}
+
+func TestStreamAccumulator_OpenAIStream(t *testing.T) {
+	client := newTestOpenAIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "data: {\"id\":\"c1\",\"choices\":[{\"index\":0,\"delta\":{\"role\":\"assistant\",\"tool_calls\":[{\"index\":0,\"id\":\"call_1\",\"type\":\"function\",\"function\":{\"name\":\"get_weather\",\"arguments\":\"\"}}]}}]}\n\n")
+		io.WriteString(w, "data: {\"id\":\"c1\",\"choices\":[{\"index\":0,\"delta\":{\"tool_calls\":[{\"index\":0,\"function\":{\"arguments\":\"{\\\"city\\\"\"}}]}}]}\n\n")
+		io.WriteString(w, "data: {\"id\":\"c1\",\"choices\":[{\"index\":0,\"delta\":{\"tool_calls\":[{\"index\":0,\"function\":{\"arguments\":\":\\\"Paris\\\"}\"}}]},\"finish_reason\":\"tool_calls\"}]}\n\n")
+		io.WriteString(w, "data: [DONE]\n\n")
+	})
+	stream, err := client.Chat.CreateStream(context.Background(), &ChatRequest{Model: "m", Messages: []Message{{Role: "user", Content: "hi"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var acc StreamAccumulator
+	for {
+		chunk, err := stream.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		acc.Add(chunk)
+	}
+	stream.Close()
+	tc := acc.Response().Choices[0].Message.ToolCalls
+	if len(tc) != 1 || tc[0].ID != "call_1" || tc[0].Function.Arguments != `{"city":"Paris"}` {
+		t.Errorf("ToolCalls = %+v", tc)
+	}
+}
@@ -0,0 +1,254 @@
+package llm
+
+import (
+	"context"
+	"errors"
+)
+
+// DefaultAgentMaxIterations is the number of model calls an Agent makes when MaxIterations is zero.
+const DefaultAgentMaxIterations = 10
+
+// Agent loop errors. Run returns them together with the transcript so far.
+var (
+	ErrMaxIterations       = errors.New("llm: agent reached max iterations")
+	ErrTokenBudgetExceeded = errors.New("llm: agent token budget exceeded")
+)
+
+// ErrToolCallDenied is reported to the model, as the tool message content, when Approve
+// rejects a tool call.
+var ErrToolCallDenied = errors.New("llm: tool call denied")
+
+// AgentStep describes one model call of an Agent run and the tool calls it triggered.
+type AgentStep struct {
+	// Index is the 0-based step number.
+	Index    int
+	Response *ChatResponse
+	// ToolCalls are the calls requested by the model; empty for the final answer.
+	ToolCalls []ToolCall
+	// ToolResults are the tool messages answering ToolCalls, in the same order.
+	ToolResults []Message
+	// ToolErrors are the errors of failed or denied tool calls. They were also sent to
+	// the model, so the run continues.
+	ToolErrors []error
+	// Usage is the usage aggregated over the run so far.
+	Usage Usage
+}
+
+// AgentResult is the outcome of an Agent run.
+type AgentResult struct {
+	// Messages is the full transcript: the request messages followed by every assistant
+	// and tool message.
+	Messages []Message
+	// Response is the last model response; its message is the final answer when Run succeeds.
+	Response *ChatResponse
+	// Usage is aggregated over every model call.
+	Usage Usage
+	// Steps is the number of model calls made.
+	Steps int
+}
+
+// Agent runs the tool-calling loop: it sends a ChatRequest, executes the returned ToolCalls
+// through Tools, appends the assistant and tool messages and repeats until the model answers
+// without tool calls, MaxIterations is reached or TokenBudget is spent.
+type Agent struct {
+	Provider ChatProvider
+	Tools    *ToolRegistry
+	// MaxIterations caps the number of model calls (default DefaultAgentMaxIterations).
+	MaxIterations int
+	// TokenBudget stops the run once Usage.TotalTokens reaches it and the model still wants
+	// to call tools. The assistant message with those calls is left out of
+	// AgentResult.Messages, since it has no tool results. 0 means no budget.
+	TokenBudget int
+	// Approve, if set, is called before each tool call. Returning false denies the call and
+	// reports ErrToolCallDenied to the model; returning an error aborts the run.
+	Approve func(ctx context.Context, call ToolCall) (bool, error)
+	// OnStep, if set, is called after each step. Returning an error aborts the run.
+	OnStep func(ctx context.Context, step AgentStep) error
+}
+
+// RunTools runs req through p, executing tool calls with tools until the model gives a final
+// answer. It is shorthand for an Agent with default limits.
+func RunTools(ctx context.Context, p ChatProvider, tools *ToolRegistry, req *ChatRequest) (*AgentResult, error) {
+	a := &Agent{Provider: p, Tools: tools}
+	return a.Run(ctx, req)
+}
+
+// Run executes the loop for req, which is not modified. When req.Tools is empty the registry's
+// tools are sent. Tool calls run concurrently when req.ParallelToolCalls is true.
+// On error the result holds the transcript and usage up to the failure.
+func (a *Agent) Run(ctx context.Context, req *ChatRequest) (*AgentResult, error) {
+	create := func(ctx context.Context, req *ChatRequest, _ int) (*ChatResponse, error) {
+		return a.Provider.Create(ctx, req)
+	}
+	return a.run(ctx, req, create, nil)
+}
+
+// run is the loop shared by Run and RunStream. create performs one model call; emit, if set,
+// receives ToolResult and StepFinished events and returning false stops the run with
+// errAgentStopped.
+func (a *Agent) run(ctx context.Context, req *ChatRequest, create func(context.Context, *ChatRequest, int) (*ChatResponse, error), emit func(AgentEvent) bool) (*AgentResult, error) {
+	if req == nil {
+		return nil, &ValidationError{Field: "request", Message: "cannot be nil"}
+	}
+	r := a.request(req)
+	res := &AgentResult{Messages: r.Messages}
+	for i := 0; ; i++ {
+		if i >= a.maxIterations() {
+			return res, ErrMaxIterations
+		}
+		resp, err := create(ctx, &r, i)
+		if err != nil {
+			return res, err
+		}
+		res.Steps++
+		res.Response = resp
+		addUsage(&res.Usage, resp.Usage)
+		if len(resp.Choices) == 0 || resp.Choices[0].Message == nil {
+			return res, &ValidationError{Field: "response", Message: "no message returned"}
+		}
+		msg := *resp.Choices[0].Message
+		if msg.Role == "" {
+			msg.Role = "assistant"
+		}
+		r.Messages = append(r.Messages, msg)
+		res.Messages = r.Messages
+
+		step := AgentStep{Index: i, Response: resp, ToolCalls: msg.ToolCalls, Usage: res.Usage}
+		if len(msg.ToolCalls) > 0 && !a.overBudget(res.Usage) {
+			var onResult func(int, Message, error) bool
+			if emit != nil {
+				onResult = func(pos int, m Message, err error) bool {
+					return emit(ToolResult{Step: i, Call: msg.ToolCalls[pos], Message: m, Err: err})
+				}
+			}
+			step.ToolResults, step.ToolErrors, err = a.callTools(ctx, msg.ToolCalls, parallelTools(&r), onResult)
+			if err != nil {
+				return res, err
+			}
+			r.Messages = append(r.Messages, step.ToolResults...)
+			res.Messages = r.Messages
+		} else if len(msg.ToolCalls) > 0 {
+			// Over budget: drop the unanswered tool calls so the transcript stays valid
+			// for a follow-up request. They remain available in res.Response.
+			r.Messages = r.Messages[:len(r.Messages)-1]
+			res.Messages = r.Messages
+		}
+		if a.OnStep != nil {
+			if err := a.OnStep(ctx, step); err != nil {
+				return res, err
+			}
+		}
+		if emit != nil && !emit(StepFinished{Step: step}) {
+			return res, errAgentStopped
+		}
+		if len(msg.ToolCalls) == 0 {
+			return res, nil
+		}
+		if step.ToolResults == nil {
+			return res, ErrTokenBudgetExceeded
+		}
+	}
+}
+
+// request returns a copy of req with its own message slice and the registry's tools.
+func (a *Agent) request(req *ChatRequest) ChatRequest {
+	r := *req
+	r.Stream = false
+	r.Messages = append([]Message(nil), req.Messages...)
+	if len(r.Tools) == 0 && a.Tools != nil {
+		r.Tools = a.Tools.Tools()
+	}
+	return r
+}
+
+func (a *Agent) maxIterations() int {
+	if a.MaxIterations <= 0 {
+		return DefaultAgentMaxIterations
+	}
+	return a.MaxIterations
+}
+
+func (a *Agent) overBudget(u Usage) bool {
+	return a.TokenBudget > 0 && u.TotalTokens >= a.TokenBudget
+}
+
+func parallelTools(req *ChatRequest) bool {
+	return req.ParallelToolCalls != nil && *req.ParallelToolCalls
+}
+
+// callTools asks for approval of each call in order, then runs the approved calls, concurrently
+// when parallel is set. It returns one tool message per call and the per-call errors; the
+// returned error is set only when Approve aborts the run, onResult returns false or ctx is done.
+// onResult, if set, is called on the calling goroutine as each call completes.
+func (a *Agent) callTools(ctx context.Context, calls []ToolCall, parallel bool, onResult func(pos int, msg Message, err error) bool) ([]Message, []error, error) {
+	tools := a.Tools
+	if tools == nil {
+		tools = NewToolRegistry()
+	}
+	msgs := make([]Message, len(calls))
+	errs := make([]error, len(calls))
+	var pending []int
+	for i, call := range calls {
+		if a.Approve != nil {
+			ok, err := a.Approve(ctx, call)
+			if err != nil {
+				return nil, nil, err
+			}
+			if !ok {
+				errs[i] = ErrToolCallDenied
+				msgs[i] = Message{Role: "tool", ToolCallID: call.ID, Name: call.Function.Name, Content: "error: " + ErrToolCallDenied.Error()}
+				if onResult != nil && !onResult(i, msgs[i], errs[i]) {
+					return nil, nil, errAgentStopped
+				}
+				continue
+			}
+		}
+		pending = append(pending, i)
+	}
+	if parallel {
+		done := make(chan int, len(pending))
+		for _, i := range pending {
+			go func() {
+				msgs[i], errs[i] = tools.Call(ctx, calls[i])
+				done <- i
+			}()
+		}
+		stopped := false
+		for range pending {
+			i := <-done
+			if onResult != nil && !stopped && !onResult(i, msgs[i], errs[i]) {
+				stopped = true
+			}
+		}
+		if stopped {
+			return nil, nil, errAgentStopped
+		}
+	} else {
+		for _, i := range pending {
+			msgs[i], errs[i] = tools.Call(ctx, calls[i])
+			if onResult != nil && !onResult(i, msgs[i], errs[i]) {
+				return nil, nil, errAgentStopped
+			}
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	var failed []error
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err)
+		}
+	}
+	return msgs, failed, nil
+}
+
+func addUsage(total *Usage, u *Usage) {
+	if u == nil {
+		return
+	}
+	total.PromptTokens += u.PromptTokens
+	total.CompletionTokens += u.CompletionTokens
+	total.TotalTokens += u.TotalTokens
+	total.Cost += u.Cost
+}
@@ -0,0 +1,157 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"iter"
+)
+
+// AgentEvent is an event emitted by Agent.RunStream: TextDelta, ReasoningDelta,
+// ToolCallStarted, ToolCallArgumentsDelta, ToolResult, StepFinished or Done.
+type AgentEvent interface {
+	agentEvent()
+}
+
+// TextDelta carries a piece of the assistant's text content.
+type TextDelta struct {
+	Step int
+	Text string
+}
+
+// ReasoningDelta carries a piece of the assistant's reasoning.
+type ReasoningDelta struct {
+	Step int
+	Text string
+}
+
+// ToolCallStarted is emitted when the model starts a tool call. Index is the call's position
+// in the step's ToolCalls.
+type ToolCallStarted struct {
+	Step  int
+	Index int
+	ID    string
+	Name  string
+}
+
+// ToolCallArgumentsDelta carries a piece of a tool call's JSON arguments.
+type ToolCallArgumentsDelta struct {
+	Step  int
+	Index int
+	Delta string
+}
+
+// ToolResult is emitted when a tool call has been executed or denied. Err is the tool's error,
+// which was also sent to the model as Message.Content.
+type ToolResult struct {
+	Step    int
+	Call    ToolCall
+	Message Message
+	Err     error
+}
+
+// StepFinished is emitted after each model call and the tool calls it triggered.
+type StepFinished struct {
+	Step AgentStep
+}
+
+// Done is the last event of a run, carrying the same result and error Agent.Run returns.
+type Done struct {
+	Result *AgentResult
+	Err    error
+}
+
+func (TextDelta) agentEvent()              {}
+func (ReasoningDelta) agentEvent()         {}
+func (ToolCallStarted) agentEvent()        {}
+func (ToolCallArgumentsDelta) agentEvent() {}
+func (ToolResult) agentEvent()             {}
+func (StepFinished) agentEvent()           {}
+func (Done) agentEvent()                   {}
+
+// errAgentStopped ends a streamed run when the consumer stops iterating.
+var errAgentStopped = errors.New("llm: agent stream stopped")
+
+// RunStream runs the same loop as Run over ChatProvider.CreateStream and yields events as they
+// happen. Tool calls are reassembled from their deltas before being executed. The last event is
+// Done; breaking out of the loop early closes the current stream and stops the run.
+//
+//	for ev := range agent.RunStream(ctx, req) {
+//		switch ev := ev.(type) {
+//		case llm.TextDelta:
+//			fmt.Print(ev.Text)
+//		case llm.ToolCallStarted:
+//			fmt.Printf("\n[calling %s]\n", ev.Name)
+//		case llm.Done:
+//			err = ev.Err
+//		}
+//	}
+func (a *Agent) RunStream(ctx context.Context, req *ChatRequest) iter.Seq[AgentEvent] {
+	return func(yield func(AgentEvent) bool) {
+		create := func(ctx context.Context, req *ChatRequest, step int) (*ChatResponse, error) {
+			return a.streamStep(ctx, req, step, yield)
+		}
+		res, err := a.run(ctx, req, create, yield)
+		if errors.Is(err, errAgentStopped) {
+			return
+		}
+		yield(Done{Result: res, Err: err})
+	}
+}
+
+// streamStep performs one streamed model call, yielding deltas, and returns the accumulated response.
+func (a *Agent) streamStep(ctx context.Context, req *ChatRequest, step int, yield func(AgentEvent) bool) (*ChatResponse, error) {
+	r := *req
+	r.Stream = true
+	stream, err := a.Provider.CreateStream(ctx, &r)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	var acc StreamAccumulator
+	stopped := false
+	emit := func(ev AgentEvent) {
+		if !stopped && !yield(ev) {
+			stopped = true
+		}
+	}
+	onToolCall := func(pos int, started bool, delta ToolCall) {
+		if started {
+			emit(ToolCallStarted{Step: step, Index: pos, ID: delta.ID, Name: delta.Function.Name})
+		}
+		if delta.Function.Arguments != "" {
+			emit(ToolCallArgumentsDelta{Step: step, Index: pos, Delta: delta.Function.Arguments})
+		}
+	}
+	for {
+		chunk, err := stream.Next()
+		if chunk != nil {
+			for _, ch := range chunk.Choices {
+				d := ch.Delta
+				if d == nil {
+					d = ch.Message
+				}
+				if ch.Index != 0 || d == nil {
+					continue
+				}
+				if d.Reasoning != "" {
+					emit(ReasoningDelta{Step: step, Text: d.Reasoning})
+				}
+				if text := contentText(d.Content); text != "" {
+					emit(TextDelta{Step: step, Text: text})
+				}
+			}
+			acc.add(chunk, onToolCall)
+		}
+		if stopped {
+			return nil, errAgentStopped
+		}
+		if errors.Is(err, io.EOF) {
+			return acc.Response(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// scriptedStreams returns one MockStreamReader per CreateStream call.
+func scriptedStreams(steps ...[]*StreamChunk) (*MockChatProvider, *[]*MockStreamReader) {
+	var streams []*MockStreamReader
+	return &MockChatProvider{CreateStreamFunc: func(ctx context.Context, req *ChatRequest) (StreamReader, error) {
+		if !req.Stream {
+			return nil, errors.New("request not marked as streaming")
+		}
+		if len(streams) >= len(steps) {
+			return nil, errTransient
+		}
+		s := &MockStreamReader{Chunks: steps[len(streams)]}
+		streams = append(streams, s)
+		return s, nil
+	}}, &streams
+}
+
+func deltaChunk(d Message) *StreamChunk {
+	return &StreamChunk{Choices: []Choice{{Delta: &d}}}
+}
+
+func eventString(ev AgentEvent) string {
+	switch ev := ev.(type) {
+	case TextDelta:
+		return fmt.Sprintf("text %d %q", ev.Step, ev.Text)
+	case ReasoningDelta:
+		return fmt.Sprintf("reasoning %d %q", ev.Step, ev.Text)
+	case ToolCallStarted:
+		return fmt.Sprintf("started %d %d %s %s", ev.Step, ev.Index, ev.ID, ev.Name)
+	case ToolCallArgumentsDelta:
+		return fmt.Sprintf("args %d %d %s", ev.Step, ev.Index, ev.Delta)
+	case ToolResult:
+		return fmt.Sprintf("result %d %s %v", ev.Step, ev.Message.Content, ev.Err)
+	case StepFinished:
+		return fmt.Sprintf("step %d %d", ev.Step.Index, len(ev.Step.ToolCalls))
+	case Done:
+		return fmt.Sprintf("done %v", ev.Err)
+	}
+	return fmt.Sprintf("%T", ev)
+}
+
+func TestAgent_RunStream(t *testing.T) {
+	p, streams := scriptedStreams(
+		[]*StreamChunk{
+			deltaChunk(Message{Role: "assistant", Reasoning: "need weather"}),
+			deltaChunk(Message{ToolCalls: []ToolCall{{Index: intPtr(0), ID: "c1", Type: "function", Function: FunctionCall{Name: "get_weather", Arguments: `{"city":`}}}}),
+			deltaChunk(Message{ToolCalls: []ToolCall{{Index: intPtr(0), Function: FunctionCall{Arguments: `"Paris"}`}}}}),
+			{Usage: &Usage{TotalTokens: 10}},
+		},
+		[]*StreamChunk{
+			deltaChunk(Message{Content: "Sun"}),
+			deltaChunk(Message{Content: "ny"}),
+			{Usage: &Usage{TotalTokens: 5}},
+		},
+	)
+	a := &Agent{Provider: p, Tools: newWeatherRegistry(t)}
+	var got []string
+	var done Done
+	for ev := range a.RunStream(context.Background(), &ChatRequest{Model: "m", Messages: []Message{{Role: "user", Content: "weather?"}}}) {
+		got = append(got, eventString(ev))
+		if d, ok := ev.(Done); ok {
+			done = d
+		}
+	}
+	want := []string{
+		`reasoning 0 "need weather"`,
+		`started 0 0 c1 get_weather`,
+		`args 0 0 {"city":`,
+		`args 0 0 "Paris"}`,
+		`result 0 {"temp_c":21.5} <nil>`,
+		`step 0 1`,
+		`text 1 "Sun"`,
+		`text 1 "ny"`,
+		`step 1 0`,
+		`done <nil>`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("events:\n%v\nwant:\n%v", got, want)
+	}
+	res := done.Result
+	if res == nil || res.Usage.TotalTokens != 15 || len(res.Messages) != 4 || res.Messages[3].Content != "Sunny" {
+		t.Fatalf("result = %+v", res)
+	}
+	if args := res.Messages[1].ToolCalls[0].Function.Arguments; args != `{"city":"Paris"}` {
+		t.Errorf("reassembled arguments = %s", args)
+	}
+	for i, s := range *streams {
+		if !s.Closed {
+			t.Errorf("stream %d not closed", i)
+		}
+	}
+}
+
+func TestAgent_RunStreamBreak(t *testing.T) {
+	p, streams := scriptedStreams([]*StreamChunk{
+		deltaChunk(Message{Content: "a"}),
+		deltaChunk(Message{Content: "b"}),
+	})
+	a := &Agent{Provider: p}
+	n := 0
+	for range a.RunStream(context.Background(), &ChatRequest{Model: "m", Messages: []Message{{Role: "user", Content: "hi"}}}) {
+		n++
+		break
+	}
+	if n != 1 || !(*streams)[0].Closed {
+		t.Errorf("events = %d, closed = %v", n, (*streams)[0].Closed)
+	}
+}
+
+func TestAgent_RunStreamError(t *testing.T) {
+	p, _ := scriptedStreams()
+	a := &Agent{Provider: p}
+	var last AgentEvent
+	for ev := range a.RunStream(context.Background(), &ChatRequest{Model: "m", Messages: []Message{{Role: "user", Content: "hi"}}}) {
+		last = ev
+	}
+	done, ok := last.(Done)
+	if !ok || !errors.Is(done.Err, errTransient) {
+		t.Errorf("last event = %#v", last)
+	}
+}
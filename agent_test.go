@@ -0,0 +1,198 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func toolCallResponse(calls ...ToolCall) *ChatResponse {
+	return &ChatResponse{
+		Choices: []Choice{{Message: &Message{Role: "assistant", ToolCalls: calls}, FinishReason: "tool_calls"}},
+		Usage:   &Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+	}
+}
+
+func weatherCall(id, city string) ToolCall {
+	return ToolCall{ID: id, Type: "function", Function: FunctionCall{Name: "get_weather", Arguments: `{"city":"` + city + `"}`}}
+}
+
+// scriptedChat returns responses in order and records the requests it received.
+func scriptedChat(responses ...*ChatResponse) (*MockChatProvider, *[]ChatRequest) {
+	var reqs []ChatRequest
+	return &MockChatProvider{CreateFunc: func(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+		reqs = append(reqs, *req)
+		if len(reqs) > len(responses) {
+			return toolCallResponse(weatherCall("again", "Paris")), nil
+		}
+		return responses[len(reqs)-1], nil
+	}}, &reqs
+}
+
+func TestAgent_Run(t *testing.T) {
+	p, reqs := scriptedChat(
+		toolCallResponse(weatherCall("c1", "Paris"), weatherCall("c2", "Oslo")),
+		&ChatResponse{Choices: []Choice{{Message: &Message{Content: "Sunny"}, FinishReason: "stop"}}, Usage: &Usage{TotalTokens: 20, Cost: 0.5}},
+	)
+	req := &ChatRequest{Model: "m", Messages: []Message{{Role: "user", Content: "weather?"}}}
+	var steps []AgentStep
+	a := &Agent{Provider: p, Tools: newWeatherRegistry(t), OnStep: func(ctx context.Context, s AgentStep) error {
+		steps = append(steps, s)
+		return nil
+	}}
+	res, err := a.Run(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(req.Messages) != 1 || req.Tools != nil {
+		t.Error("Run should not modify the request")
+	}
+	if len((*reqs)[0].Tools) != 2 {
+		t.Errorf("registry tools not sent: %+v", (*reqs)[0].Tools)
+	}
+	if res.Steps != 2 || res.Usage.TotalTokens != 35 || res.Usage.Cost != 0.5 {
+		t.Errorf("result = %+v", res)
+	}
+	roles := []string{"user", "assistant", "tool", "tool", "assistant"}
+	if len(res.Messages) != len(roles) {
+		t.Fatalf("transcript = %+v", res.Messages)
+	}
+	for i, role := range roles {
+		if res.Messages[i].Role != role {
+			t.Errorf("message %d role = %q, want %q", i, res.Messages[i].Role, role)
+		}
+	}
+	if res.Messages[2].ToolCallID != "c1" || res.Messages[3].ToolCallID != "c2" || res.Messages[4].Content != "Sunny" {
+		t.Errorf("transcript = %+v", res.Messages)
+	}
+	if len((*reqs)[1].Messages) != 4 {
+		t.Errorf("second request messages = %d, want 4", len((*reqs)[1].Messages))
+	}
+	if len(steps) != 2 || len(steps[0].ToolResults) != 2 || steps[1].Index != 1 || steps[1].Usage.TotalTokens != 35 {
+		t.Errorf("steps = %+v", steps)
+	}
+}
+
+func TestAgent_ToolErrorsAreSentToModel(t *testing.T) {
+	p, _ := scriptedChat(
+		toolCallResponse(ToolCall{ID: "c1", Function: FunctionCall{Name: "missing"}}),
+		&ChatResponse{Choices: []Choice{{Message: &Message{Content: "sorry"}}}},
+	)
+	var step AgentStep
+	a := &Agent{Provider: p, Tools: newWeatherRegistry(t), OnStep: func(ctx context.Context, s AgentStep) error {
+		if s.Index == 0 {
+			step = s
+		}
+		return nil
+	}}
+	res, err := a.Run(context.Background(), &ChatRequest{Model: "m", Messages: []Message{{Role: "user", Content: "hi"}}})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(step.ToolErrors) != 1 || !errors.Is(step.ToolErrors[0], &ErrUnknownTool{}) {
+		t.Errorf("tool errors = %v", step.ToolErrors)
+	}
+	if res.Messages[2].Role != "tool" || res.Messages[2].ToolCallID != "c1" {
+		t.Errorf("tool message = %+v", res.Messages[2])
+	}
+}
+
+func TestAgent_Parallel(t *testing.T) {
+	r := NewToolRegistry()
+	var running, peak atomic.Int32
+	RegisterTool(r, "slow", "", func(ctx context.Context, a struct{}) (string, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			old := peak.Load()
+			if n <= old || peak.CompareAndSwap(old, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		return "ok", nil
+	})
+	calls := []ToolCall{{ID: "a", Function: FunctionCall{Name: "slow"}}, {ID: "b", Function: FunctionCall{Name: "slow"}}, {ID: "c", Function: FunctionCall{Name: "slow"}}}
+	final := &ChatResponse{Choices: []Choice{{Message: &Message{Content: "done"}}}}
+
+	for _, parallel := range []bool{false, true} {
+		peak.Store(0)
+		p, _ := scriptedChat(toolCallResponse(calls...), final)
+		req := &ChatRequest{Model: "m", Messages: []Message{{Role: "user", Content: "go"}}, ParallelToolCalls: &parallel}
+		res, err := RunTools(context.Background(), p, r, req)
+		if err != nil {
+			t.Fatalf("RunTools: %v", err)
+		}
+		if res.Messages[2].ToolCallID != "a" || res.Messages[4].ToolCallID != "c" {
+			t.Errorf("tool results out of order: %+v", res.Messages)
+		}
+		if got := peak.Load(); parallel != (got > 1) {
+			t.Errorf("parallel=%v: peak concurrency = %d", parallel, got)
+		}
+	}
+}
+
+func TestAgent_Approve(t *testing.T) {
+	p, _ := scriptedChat(
+		toolCallResponse(weatherCall("c1", "Paris"), weatherCall("c2", "Oslo")),
+		&ChatResponse{Choices: []Choice{{Message: &Message{Content: "ok"}}}},
+	)
+	a := &Agent{Provider: p, Tools: newWeatherRegistry(t), Approve: func(ctx context.Context, call ToolCall) (bool, error) {
+		return call.ID == "c1", nil
+	}}
+	res, err := a.Run(context.Background(), &ChatRequest{Model: "m", Messages: []Message{{Role: "user", Content: "hi"}}})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.Messages[2].Content != `{"temp_c":21.5}` || res.Messages[3].Content != "error: "+ErrToolCallDenied.Error() {
+		t.Errorf("tool messages = %+v", res.Messages[2:4])
+	}
+
+	abort := errors.New("stop")
+	p, _ = scriptedChat(toolCallResponse(weatherCall("c1", "Paris")))
+	a = &Agent{Provider: p, Tools: newWeatherRegistry(t), Approve: func(ctx context.Context, call ToolCall) (bool, error) {
+		return false, abort
+	}}
+	if _, err := a.Run(context.Background(), &ChatRequest{Model: "m", Messages: []Message{{Role: "user", Content: "hi"}}}); !errors.Is(err, abort) {
+		t.Errorf("err = %v, want %v", err, abort)
+	}
+}
+
+func TestAgent_Limits(t *testing.T) {
+	p, reqs := scriptedChat()
+	a := &Agent{Provider: p, Tools: newWeatherRegistry(t), MaxIterations: 3}
+	res, err := a.Run(context.Background(), &ChatRequest{Model: "m", Messages: []Message{{Role: "user", Content: "hi"}}})
+	if !errors.Is(err, ErrMaxIterations) {
+		t.Fatalf("err = %v, want ErrMaxIterations", err)
+	}
+	if len(*reqs) != 3 || res.Steps != 3 || len(res.Messages) != 7 {
+		t.Errorf("calls = %d, result = %+v", len(*reqs), res)
+	}
+
+	p, reqs = scriptedChat()
+	a = &Agent{Provider: p, Tools: newWeatherRegistry(t), TokenBudget: 30}
+	res, err = a.Run(context.Background(), &ChatRequest{Model: "m", Messages: []Message{{Role: "user", Content: "hi"}}})
+	if !errors.Is(err, ErrTokenBudgetExceeded) {
+		t.Fatalf("err = %v, want ErrTokenBudgetExceeded", err)
+	}
+	// The second response reaches the budget; its tool calls are not executed and its
+	// message is left out of the transcript, which ends with the first step's tool results.
+	if len(*reqs) != 2 || res.Usage.TotalTokens != 30 || len(res.Messages) != 3 || res.Messages[2].Role != "tool" {
+		t.Errorf("calls = %d, result = %+v", len(*reqs), res)
+	}
+	if len(res.Response.Choices[0].Message.ToolCalls) == 0 {
+		t.Errorf("response = %+v, want the unanswered tool calls", res.Response)
+	}
+}
+
+func TestAgent_ProviderError(t *testing.T) {
+	p := &MockChatProvider{CreateFunc: func(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+		return nil, errTransient
+	}}
+	res, err := RunTools(context.Background(), p, nil, &ChatRequest{Model: "m", Messages: []Message{{Role: "user", Content: "hi"}}})
+	if !errors.Is(err, errTransient) || res == nil || len(res.Messages) != 1 {
+		t.Errorf("res = %+v, err = %v", res, err)
+	}
+}
@@ -0,0 +1,548 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// DefaultAnthropicBaseURL is the default base URL for the Anthropic API.
+const DefaultAnthropicBaseURL = "https://api.anthropic.com/v1"
+
+// AnthropicVersion is the value sent in the anthropic-version header.
+const AnthropicVersion = "2023-06-01"
+
+// DefaultAnthropicMaxTokens is used when ChatRequest.MaxTokens is nil, since the
+// Messages API requires max_tokens on every request.
+const DefaultAnthropicMaxTokens = 4096
+
+type anthropicChat struct {
+	c *caller
+}
+
+func newAnthropicClient(cfg *config) (*Client, error) {
+	apiKey := cfg.APIKey
+	if apiKey == "" {
+		apiKey = os.Getenv("ANTHROPIC_API_KEY")
+	}
+	if apiKey == "" {
+		return nil, fmt.Errorf("%w: set ANTHROPIC_API_KEY or use WithAPIKey()", ErrMissingAPIKey)
+	}
+	c := newCaller(ProviderAnthropic, cfg, DefaultAnthropicBaseURL, map[string]string{
+		"x-api-key":         apiKey,
+		"anthropic-version": AnthropicVersion,
+	})
+	return &Client{
+		Chat:       &anthropicChat{c: c},
+		Embeddings: &unsupportedEmbedding{provider: ProviderAnthropic},
+	}, nil
+}
+
+func (p *anthropicChat) Create(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	if err := validateChatRequest(req); err != nil {
+		return nil, err
+	}
+	aReq, err := toAnthropicRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	var resp anthropicResponse
+	if err := p.c.doPost(ctx, "/messages", aReq, &resp); err != nil {
+		return nil, err
+	}
+	return resp.toLLM(), nil
+}
+
+func (p *anthropicChat) CreateStream(ctx context.Context, req *ChatRequest) (StreamReader, error) {
+	if err := validateChatRequest(req); err != nil {
+		return nil, err
+	}
+	aReq, err := toAnthropicRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	aReq.Stream = true
+	body, err := p.c.doStreamPost(ctx, "/messages", aReq)
+	if err != nil {
+		return nil, err
+	}
+	return &anthropicStreamReader{body: body, dec: newSSEDecoder(body), toolIndex: make(map[int]int)}, nil
+}
+
+// unsupportedEmbedding is the EmbeddingProvider for backends without an embeddings API.
+type unsupportedEmbedding struct {
+	provider Provider
+}
+
+func (u *unsupportedEmbedding) Create(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error) {
+	return nil, fmt.Errorf("%w: %s does not provide embeddings", ErrUnsupported, u.provider)
+}
+
+// anthropicStreamReader maps Messages API stream events onto StreamChunk deltas.
+// Tool-use blocks are numbered in order of appearance so ToolCall.Index matches the
+// OpenAI convention regardless of interleaved text and thinking blocks.
+type anthropicStreamReader struct {
+	body      io.ReadCloser
+	dec       *sseDecoder
+	done      bool
+	id        string
+	model     string
+	usage     Usage
+	toolIndex map[int]int
+}
+
+func (s *anthropicStreamReader) Next() (*StreamChunk, error) {
+	if s.done {
+		return nil, io.EOF
+	}
+	for {
+		ev, err := s.dec.Next()
+		if err != nil {
+			if err == io.EOF {
+				s.done = true
+			}
+			return nil, err
+		}
+		var e anthropicStreamEvent
+		if err := json.Unmarshal(ev.Data, &e); err != nil {
+			return nil, fmt.Errorf("llm: anthropic: malformed stream event: %w", err)
+		}
+		switch e.Type {
+		case "message_start":
+			if e.Message != nil {
+				s.id = e.Message.ID
+				s.model = e.Message.Model
+				if e.Message.Usage != nil {
+					s.usage.PromptTokens = e.Message.Usage.promptTokens()
+					s.usage.CompletionTokens = e.Message.Usage.OutputTokens
+				}
+			}
+			return s.chunk(Choice{Delta: &Message{Role: "assistant"}}), nil
+		case "content_block_start":
+			if e.ContentBlock == nil || e.ContentBlock.Type != "tool_use" {
+				continue
+			}
+			idx := len(s.toolIndex)
+			s.toolIndex[e.Index] = idx
+			return s.chunk(Choice{Delta: &Message{ToolCalls: []ToolCall{{
+				Index:    &idx,
+				ID:       e.ContentBlock.ID,
+				Type:     "function",
+				Function: FunctionCall{Name: e.ContentBlock.Name},
+			}}}}), nil
+		case "content_block_delta":
+			if e.Delta == nil {
+				continue
+			}
+			switch e.Delta.Type {
+			case "text_delta":
+				return s.chunk(Choice{Delta: &Message{Content: e.Delta.Text}}), nil
+			case "thinking_delta":
+				return s.chunk(Choice{Delta: &Message{Reasoning: e.Delta.Thinking}}), nil
+			case "input_json_delta":
+				idx, ok := s.toolIndex[e.Index]
+				if !ok {
+					continue
+				}
+				return s.chunk(Choice{Delta: &Message{ToolCalls: []ToolCall{{
+					Index:    &idx,
+					Function: FunctionCall{Arguments: e.Delta.PartialJSON},
+				}}}}), nil
+			}
+		case "message_delta":
+			if e.Usage != nil {
+				s.usage.CompletionTokens = e.Usage.OutputTokens
+			}
+			s.usage.TotalTokens = s.usage.PromptTokens + s.usage.CompletionTokens
+			usage := s.usage
+			chunk := s.chunk(Choice{Delta: &Message{}})
+			if e.Delta != nil {
+				chunk.Choices[0].FinishReason = anthropicFinishReason(e.Delta.StopReason)
+			}
+			chunk.Usage = &usage
+			return chunk, nil
+		case "message_stop":
+			s.done = true
+			return nil, io.EOF
+		case "error":
+			apiErr := &APIError{Provider: ProviderAnthropic, Message: "unknown error"}
+			if e.Error != nil {
+				apiErr.Code, apiErr.Message = e.Error.Type, e.Error.Message
+			}
+			return nil, apiErr
+		}
+	}
+}
+
+func (s *anthropicStreamReader) chunk(ch Choice) *StreamChunk {
+	return &StreamChunk{
+		ID:      s.id,
+		Object:  "chat.completion.chunk",
+		Model:   s.model,
+		Choices: []Choice{ch},
+	}
+}
+
+func (s *anthropicStreamReader) Close() error {
+	s.done = true
+	return s.body.Close()
+}
+
+// Anthropic Messages API wire format.
+
+type anthropicRequest struct {
+	Model         string               `json:"model"`
+	System        string               `json:"system,omitempty"`
+	Messages      []anthropicMessage   `json:"messages"`
+	MaxTokens     int                  `json:"max_tokens"`
+	Temperature   *float64             `json:"temperature,omitempty"`
+	TopP          *float64             `json:"top_p,omitempty"`
+	TopK          *int                 `json:"top_k,omitempty"`
+	StopSequences []string             `json:"stop_sequences,omitempty"`
+	Stream        bool                 `json:"stream,omitempty"`
+	Tools         []anthropicTool      `json:"tools,omitempty"`
+	ToolChoice    *anthropicToolChoice `json:"tool_choice,omitempty"`
+	Metadata      *anthropicMetadata   `json:"metadata,omitempty"`
+}
+
+type anthropicMessage struct {
+	Role    string           `json:"role"`
+	Content []anthropicBlock `json:"content"`
+}
+
+type anthropicBlock struct {
+	Type      string           `json:"type"`
+	Text      string           `json:"text,omitempty"`
+	Source    *anthropicSource `json:"source,omitempty"`
+	Title     string           `json:"title,omitempty"`
+	ID        string           `json:"id,omitempty"`
+	Name      string           `json:"name,omitempty"`
+	Input     json.RawMessage  `json:"input,omitempty"`
+	ToolUseID string           `json:"tool_use_id,omitempty"`
+	Content   []anthropicBlock `json:"content,omitempty"`
+	Thinking  string           `json:"thinking,omitempty"`
+	Signature string           `json:"signature,omitempty"`
+}
+
+type anthropicSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+type anthropicTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	InputSchema map[string]any `json:"input_schema"`
+}
+
+type anthropicToolChoice struct {
+	Type                   string `json:"type"`
+	Name                   string `json:"name,omitempty"`
+	DisableParallelToolUse bool   `json:"disable_parallel_tool_use,omitempty"`
+}
+
+type anthropicMetadata struct {
+	UserID string `json:"user_id,omitempty"`
+}
+
+type anthropicResponse struct {
+	ID         string           `json:"id"`
+	Type       string           `json:"type"`
+	Role       string           `json:"role"`
+	Model      string           `json:"model"`
+	Content    []anthropicBlock `json:"content"`
+	StopReason string           `json:"stop_reason"`
+	Usage      *anthropicUsage  `json:"usage,omitempty"`
+}
+
+type anthropicUsage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+}
+
+func (u *anthropicUsage) promptTokens() int {
+	return u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+}
+
+type anthropicStreamEvent struct {
+	Type         string             `json:"type"`
+	Index        int                `json:"index"`
+	Message      *anthropicResponse `json:"message,omitempty"`
+	ContentBlock *anthropicBlock    `json:"content_block,omitempty"`
+	Delta        *anthropicDelta    `json:"delta,omitempty"`
+	Usage        *anthropicUsage    `json:"usage,omitempty"`
+	Error        *anthropicError    `json:"error,omitempty"`
+}
+
+type anthropicDelta struct {
+	Type        string `json:"type"`
+	Text        string `json:"text"`
+	Thinking    string `json:"thinking"`
+	PartialJSON string `json:"partial_json"`
+	StopReason  string `json:"stop_reason"`
+}
+
+type anthropicError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// toAnthropicRequest converts req to the Messages API format. System and developer
+// messages are hoisted into the top-level system prompt, tool results become
+// tool_result blocks in a user turn, and consecutive turns of the same role are merged.
+func toAnthropicRequest(req *ChatRequest) (*anthropicRequest, error) {
+	out := &anthropicRequest{
+		Model:       req.Model,
+		MaxTokens:   DefaultAnthropicMaxTokens,
+		Temperature: req.Temperature,
+		TopP:        req.TopP,
+		TopK:        req.TopK,
+	}
+	if req.MaxTokens != nil {
+		out.MaxTokens = *req.MaxTokens
+	}
+	if req.User != "" {
+		out.Metadata = &anthropicMetadata{UserID: req.User}
+	}
+	stop, err := stopSequences(req.Stop)
+	if err != nil {
+		return nil, err
+	}
+	out.StopSequences = stop
+
+	var system []string
+	for i, m := range req.Messages {
+		field := fmt.Sprintf("messages[%d]", i)
+		var (
+			role   string
+			blocks []anthropicBlock
+		)
+		switch m.Role {
+		case "system", "developer":
+			system = append(system, contentText(m.Content))
+			continue
+		case "tool":
+			role = "user"
+			inner, err := toAnthropicBlocks(field, m.Content)
+			if err != nil {
+				return nil, err
+			}
+			blocks = []anthropicBlock{{Type: "tool_result", ToolUseID: m.ToolCallID, Content: inner}}
+		case "assistant":
+			role = "assistant"
+			inner, err := toAnthropicBlocks(field, m.Content)
+			if err != nil {
+				return nil, err
+			}
+			blocks = append(blocks, inner...)
+			for _, tc := range m.ToolCalls {
+				blocks = append(blocks, anthropicBlock{
+					Type:  "tool_use",
+					ID:    tc.ID,
+					Name:  tc.Function.Name,
+					Input: toolInput(tc.Function.Arguments),
+				})
+			}
+		default:
+			role = "user"
+			inner, err := toAnthropicBlocks(field, m.Content)
+			if err != nil {
+				return nil, err
+			}
+			blocks = inner
+		}
+		if len(blocks) == 0 {
+			continue
+		}
+		if n := len(out.Messages); n > 0 && out.Messages[n-1].Role == role {
+			out.Messages[n-1].Content = append(out.Messages[n-1].Content, blocks...)
+			continue
+		}
+		out.Messages = append(out.Messages, anthropicMessage{Role: role, Content: blocks})
+	}
+	out.System = strings.Join(system, "\n\n")
+
+	for _, t := range req.Tools {
+		schema := t.Function.Parameters
+		if schema == nil {
+			schema = map[string]any{"type": "object", "properties": map[string]any{}}
+		}
+		out.Tools = append(out.Tools, anthropicTool{
+			Name:        t.Function.Name,
+			Description: t.Function.Description,
+			InputSchema: schema,
+		})
+	}
+	out.ToolChoice = toAnthropicToolChoice(req.ToolChoice, req.ParallelToolCalls)
+	if out.ToolChoice != nil && out.ToolChoice.Type == "none" {
+		out.Tools = nil
+		out.ToolChoice = nil
+	}
+	return out, nil
+}
+
+func toAnthropicBlocks(field string, content any) ([]anthropicBlock, error) {
+	parts, err := contentParts(content)
+	if err != nil {
+		return nil, &ValidationError{Field: field + ".content", Message: err.Error()}
+	}
+	blocks := make([]anthropicBlock, 0, len(parts))
+	for j, p := range parts {
+		switch p.Type {
+		case "text":
+			if p.Text == "" {
+				continue
+			}
+			blocks = append(blocks, anthropicBlock{Type: "text", Text: p.Text})
+		case "image_url":
+			if p.ImageURL == nil {
+				return nil, &ValidationError{Field: fmt.Sprintf("%s.content[%d].image_url", field, j), Message: "cannot be nil"}
+			}
+			blocks = append(blocks, anthropicBlock{Type: "image", Source: toAnthropicSource(p.ImageURL.URL)})
+		case "file":
+			if p.File == nil {
+				return nil, &ValidationError{Field: fmt.Sprintf("%s.content[%d].file", field, j), Message: "cannot be nil"}
+			}
+			blocks = append(blocks, anthropicBlock{Type: "document", Source: toAnthropicSource(p.File.FileData), Title: p.File.Filename})
+		default:
+			return nil, &ValidationError{Field: fmt.Sprintf("%s.content[%d].type", field, j), Message: fmt.Sprintf("%q is not supported by anthropic", p.Type)}
+		}
+	}
+	return blocks, nil
+}
+
+func toAnthropicSource(u string) *anthropicSource {
+	if mediaType, data, ok := parseDataURL(u); ok {
+		return &anthropicSource{Type: "base64", MediaType: mediaType, Data: data}
+	}
+	return &anthropicSource{Type: "url", URL: u}
+}
+
+// toolInput returns tool-call arguments as a JSON object, falling back to {} for empty or invalid input.
+func toolInput(arguments string) json.RawMessage {
+	var obj map[string]json.RawMessage
+	if arguments == "" || json.Unmarshal([]byte(arguments), &obj) != nil {
+		return json.RawMessage("{}")
+	}
+	return json.RawMessage(arguments)
+}
+
+// toAnthropicToolChoice maps OpenAI-style tool_choice values ("auto", "none", "required",
+// or {"type":"function","function":{"name":...}}) to Anthropic's format.
+func toAnthropicToolChoice(choice any, parallel *bool) *anthropicToolChoice {
+	var out *anthropicToolChoice
+	switch v := choice.(type) {
+	case string:
+		switch v {
+		case "auto":
+			out = &anthropicToolChoice{Type: "auto"}
+		case "none":
+			out = &anthropicToolChoice{Type: "none"}
+		case "required", "any":
+			out = &anthropicToolChoice{Type: "any"}
+		}
+	case map[string]any:
+		if fn, ok := v["function"].(map[string]any); ok {
+			if name, _ := fn["name"].(string); name != "" {
+				out = &anthropicToolChoice{Type: "tool", Name: name}
+			}
+		}
+	}
+	if parallel != nil && !*parallel {
+		if out == nil {
+			out = &anthropicToolChoice{Type: "auto"}
+		}
+		out.DisableParallelToolUse = true
+	}
+	return out
+}
+
+// stopSequences normalizes ChatRequest.Stop (a string or list of strings).
+func stopSequences(stop any) ([]string, error) {
+	switch v := stop.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		if v == "" {
+			return nil, nil
+		}
+		return []string{v}, nil
+	case []string:
+		return v, nil
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, s := range v {
+			str, ok := s.(string)
+			if !ok {
+				return nil, &ValidationError{Field: "stop", Message: "must be a string or list of strings"}
+			}
+			out = append(out, str)
+		}
+		return out, nil
+	default:
+		return nil, &ValidationError{Field: "stop", Message: "must be a string or list of strings"}
+	}
+}
+
+func (r *anthropicResponse) toLLM() *ChatResponse {
+	msg := &Message{Role: "assistant"}
+	var text, reasoning strings.Builder
+	for _, b := range r.Content {
+		switch b.Type {
+		case "text":
+			text.WriteString(b.Text)
+		case "thinking":
+			reasoning.WriteString(b.Thinking)
+		case "tool_use":
+			args := string(b.Input)
+			if args == "" {
+				args = "{}"
+			}
+			msg.ToolCalls = append(msg.ToolCalls, ToolCall{
+				ID:       b.ID,
+				Type:     "function",
+				Function: FunctionCall{Name: b.Name, Arguments: args},
+			})
+		}
+	}
+	msg.Content = text.String()
+	msg.Reasoning = reasoning.String()
+	out := &ChatResponse{
+		ID:      r.ID,
+		Object:  "chat.completion",
+		Model:   r.Model,
+		Choices: []Choice{{Message: msg, FinishReason: anthropicFinishReason(r.StopReason)}},
+	}
+	if r.Usage != nil {
+		prompt := r.Usage.promptTokens()
+		out.Usage = &Usage{
+			PromptTokens:     prompt,
+			CompletionTokens: r.Usage.OutputTokens,
+			TotalTokens:      prompt + r.Usage.OutputTokens,
+		}
+	}
+	return out
+}
+
+// anthropicFinishReason maps Anthropic stop reasons to OpenAI-style finish reasons.
+func anthropicFinishReason(reason string) string {
+	switch reason {
+	case "end_turn", "stop_sequence", "pause_turn":
+		return "stop"
+	case "max_tokens":
+		return "length"
+	case "tool_use":
+		return "tool_calls"
+	case "refusal":
+		return "content_filter"
+	default:
+		return reason
+	}
+}
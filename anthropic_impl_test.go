@@ -0,0 +1,202 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTestAnthropicClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	client, err := NewClient(ProviderAnthropic, WithAPIKey("ak-test"), WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return client
+}
+
+func TestToAnthropicRequest(t *testing.T) {
+	parallel := false
+	req := &ChatRequest{
+		Model: "claude-sonnet-4",
+		Messages: []Message{
+			{Role: "system", Content: "be brief"},
+			{Role: "user", Content: []ContentPart{
+				{Type: "text", Text: "what is this?"},
+				{Type: "image_url", ImageURL: &ImageURL{URL: "data:image/png;base64,AAAA"}},
+				{Type: "file", File: &FileData{Filename: "doc.pdf", FileData: "https://example.com/doc.pdf"}},
+			}},
+			{Role: "assistant", Content: "checking", ToolCalls: []ToolCall{
+				{ID: "t1", Type: "function", Function: FunctionCall{Name: "lookup", Arguments: `{"q":"x"}`}},
+				{ID: "t2", Type: "function", Function: FunctionCall{Name: "lookup", Arguments: ""}},
+			}},
+			{Role: "tool", ToolCallID: "t1", Content: "result 1"},
+			{Role: "tool", ToolCallID: "t2", Content: "result 2"},
+		},
+		Tools:             []Tool{{Type: "function", Function: FunctionDef{Name: "lookup", Parameters: map[string]any{"type": "object"}}}},
+		ToolChoice:        "required",
+		ParallelToolCalls: &parallel,
+		Stop:              "END",
+	}
+	got, err := toAnthropicRequest(req)
+	if err != nil {
+		t.Fatalf("toAnthropicRequest: %v", err)
+	}
+	if got.System != "be brief" {
+		t.Errorf("System = %q", got.System)
+	}
+	if got.MaxTokens != DefaultAnthropicMaxTokens {
+		t.Errorf("MaxTokens = %d", got.MaxTokens)
+	}
+	if len(got.Messages) != 3 {
+		t.Fatalf("len(Messages) = %d, want 3 (user, assistant, merged tool results)", len(got.Messages))
+	}
+	user := got.Messages[0].Content
+	if user[1].Type != "image" || user[1].Source.Type != "base64" || user[1].Source.MediaType != "image/png" || user[1].Source.Data != "AAAA" {
+		t.Errorf("image block = %+v", user[1])
+	}
+	if user[2].Type != "document" || user[2].Source.Type != "url" || user[2].Title != "doc.pdf" {
+		t.Errorf("document block = %+v", user[2])
+	}
+	asst := got.Messages[1].Content
+	if len(asst) != 3 || asst[1].Type != "tool_use" || string(asst[1].Input) != `{"q":"x"}` || string(asst[2].Input) != "{}" {
+		t.Errorf("assistant blocks = %+v", asst)
+	}
+	results := got.Messages[2]
+	if results.Role != "user" || len(results.Content) != 2 || results.Content[1].ToolUseID != "t2" {
+		t.Errorf("tool results = %+v", results)
+	}
+	if got.ToolChoice == nil || got.ToolChoice.Type != "any" || !got.ToolChoice.DisableParallelToolUse {
+		t.Errorf("ToolChoice = %+v", got.ToolChoice)
+	}
+	if len(got.StopSequences) != 1 || got.StopSequences[0] != "END" {
+		t.Errorf("StopSequences = %v", got.StopSequences)
+	}
+}
+
+func TestToAnthropicRequest_UnsupportedPart(t *testing.T) {
+	_, err := toAnthropicRequest(&ChatRequest{
+		Model:    "m",
+		Messages: []Message{{Role: "user", Content: []ContentPart{{Type: "input_audio", InputAudio: &InputAudio{Data: "x", Format: "wav"}}}}},
+	})
+	if !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("err = %v, want ErrInvalidRequest", err)
+	}
+}
+
+func TestAnthropicChat_Create(t *testing.T) {
+	client := newTestAnthropicClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/messages" {
+			t.Errorf("path = %q, want /messages", r.URL.Path)
+		}
+		if r.Header.Get("x-api-key") != "ak-test" || r.Header.Get("anthropic-version") != AnthropicVersion {
+			t.Errorf("headers = %v", r.Header)
+		}
+		io.WriteString(w, `{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4","content":[{"type":"thinking","thinking":"hmm"},{"type":"text","text":"Let me look."},{"type":"tool_use","id":"toolu_1","name":"lookup","input":{"q":"x"}}],"stop_reason":"tool_use","usage":{"input_tokens":10,"output_tokens":5}}`)
+	})
+	resp, err := client.Chat.Create(context.Background(), &ChatRequest{Model: "claude-sonnet-4", Messages: []Message{{Role: "user", Content: "hi"}}})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	ch := resp.Choices[0]
+	if ch.FinishReason != "tool_calls" {
+		t.Errorf("FinishReason = %q", ch.FinishReason)
+	}
+	if ch.Message.Content != "Let me look." || ch.Message.Reasoning != "hmm" {
+		t.Errorf("Message = %+v", ch.Message)
+	}
+	if len(ch.Message.ToolCalls) != 1 || ch.Message.ToolCalls[0].Function.Arguments != `{"q":"x"}` {
+		t.Errorf("ToolCalls = %+v", ch.Message.ToolCalls)
+	}
+	if resp.Usage == nil || resp.Usage.TotalTokens != 15 {
+		t.Errorf("Usage = %+v", resp.Usage)
+	}
+}
+
+func TestAnthropicChat_CreateStream(t *testing.T) {
+	events := []string{
+		`event: message_start` + "\n" + `data: {"type":"message_start","message":{"id":"msg_1","model":"claude-sonnet-4","usage":{"input_tokens":10,"output_tokens":1}}}`,
+		`event: ping` + "\n" + `data: {"type":"ping"}`,
+		`data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hi"}}`,
+		`data: {"type":"content_block_stop","index":0}`,
+		`data: {"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"lookup","input":{}}}`,
+		`data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"q\":"}}`,
+		`data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"x\"}"}}`,
+		`data: {"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":7}}`,
+		`data: {"type":"message_stop"}`,
+	}
+	client := newTestAnthropicClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["stream"] != true {
+			t.Errorf("stream = %v", body["stream"])
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, strings.Join(events, "\n\n")+"\n\n")
+	})
+	stream, err := client.Chat.CreateStream(context.Background(), &ChatRequest{Model: "claude-sonnet-4", Messages: []Message{{Role: "user", Content: "hi"}}})
+	if err != nil {
+		t.Fatalf("CreateStream: %v", err)
+	}
+	defer stream.Close()
+
+	var (
+		text, args strings.Builder
+		toolID     string
+		finish     string
+		usage      *Usage
+	)
+	for {
+		chunk, err := stream.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+		d := chunk.Choices[0].Delta
+		if s, ok := d.Content.(string); ok {
+			text.WriteString(s)
+		}
+		for _, tc := range d.ToolCalls {
+			if tc.Index == nil || *tc.Index != 0 {
+				t.Errorf("tool call index = %v, want 0", tc.Index)
+			}
+			if tc.ID != "" {
+				toolID = tc.ID
+			}
+			args.WriteString(tc.Function.Arguments)
+		}
+		if chunk.Choices[0].FinishReason != "" {
+			finish = chunk.Choices[0].FinishReason
+		}
+	}
+	if text.String() != "Hi" || toolID != "toolu_1" || args.String() != `{"q":"x"}` {
+		t.Errorf("text = %q, toolID = %q, args = %q", text.String(), toolID, args.String())
+	}
+	if finish != "tool_calls" {
+		t.Errorf("finish = %q", finish)
+	}
+	if usage == nil || usage.PromptTokens != 10 || usage.CompletionTokens != 7 || usage.TotalTokens != 17 {
+		t.Errorf("usage = %+v", usage)
+	}
+}
+
+func TestAnthropicEmbeddings_Unsupported(t *testing.T) {
+	client := newTestAnthropicClient(t, func(w http.ResponseWriter, r *http.Request) {})
+	_, err := client.Embeddings.Create(context.Background(), &EmbeddingRequest{Model: "m", Input: "x"})
+	if !errors.Is(err, ErrUnsupported) {
+		t.Errorf("err = %v, want ErrUnsupported", err)
+	}
+}
@@ -0,0 +1,227 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MetaDiv-AI/logger"
+	"go.uber.org/zap"
+)
+
+// caller performs JSON-over-HTTP requests for the natively implemented providers.
+// It applies auth and custom headers and maps non-2xx responses to errors.
+// Retries are handled by the provider-agnostic retry layer (see NewRetryChatProvider).
+type caller struct {
+	provider Provider
+	baseURL  string
+	headers  map[string]string
+	client   *http.Client
+	logger   logger.Logger
+}
+
+// newCaller creates a caller from cfg. defaultBaseURL is used when cfg.BaseURL is empty;
+// auth holds provider-specific authentication headers and is applied before cfg.Headers.
+func newCaller(provider Provider, cfg *config, defaultBaseURL string, auth map[string]string) *caller {
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	headers := make(map[string]string, len(auth)+len(cfg.Headers)+1)
+	for k, v := range auth {
+		headers[k] = v
+	}
+	if cfg.ForwardedFor != "" {
+		headers["X-Forwarded-For"] = cfg.ForwardedFor
+	}
+	for k, v := range cfg.Headers {
+		headers[k] = v
+	}
+	log := cfg.Logger
+	if cfg.Debug && log == nil {
+		log = logger.New().Development().Build()
+	}
+	return &caller{
+		provider: provider,
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		headers:  headers,
+		client:   &http.Client{Timeout: cfg.Timeout},
+		logger:   log,
+	}
+}
+
+// doPost sends req as JSON to path and decodes the response body into resp.
+func (c *caller) doPost(ctx context.Context, path string, req, resp any) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	raw, err := c.send(ctx, http.MethodPost, path, body)
+	if err != nil {
+		return err
+	}
+	if resp == nil || len(raw) == 0 {
+		return nil
+	}
+	return json.Unmarshal(raw, resp)
+}
+
+// doStreamPost sends req as JSON to path and returns the open response body for streaming.
+// The caller must close the returned body.
+func (c *caller) doStreamPost(ctx context.Context, path string, req any) (io.ReadCloser, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	httpResp, err := c.do(ctx, http.MethodPost, path, body)
+	if err != nil {
+		return nil, err
+	}
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		defer httpResp.Body.Close()
+		raw, _ := io.ReadAll(httpResp.Body)
+		return nil, c.statusError(httpResp, raw)
+	}
+	return httpResp.Body, nil
+}
+
+func (c *caller) send(ctx context.Context, method, path string, body []byte) ([]byte, error) {
+	httpResp, err := c.do(ctx, method, path, body)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+	raw, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		return nil, c.statusError(httpResp, raw)
+	}
+	return raw, nil
+}
+
+func (c *caller) statusError(httpResp *http.Response, raw []byte) error {
+	e := &APIError{
+		Provider:   c.provider,
+		StatusCode: httpResp.StatusCode,
+		RequestID:  requestID(httpResp.Header),
+		RetryAfter: parseRetryAfter(httpResp.Header, time.Now()),
+		Body:       string(raw),
+	}
+	e.Code, e.Message = parseErrorBody(raw)
+	return e
+}
+
+// requestID returns the provider request ID from common response headers.
+func requestID(h http.Header) string {
+	for _, k := range []string{"X-Request-Id", "Request-Id", "X-Goog-Request-Id", "Cf-Ray"} {
+		if v := h.Get(k); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// parseErrorBody extracts the error code and message from the error envelopes used by
+// OpenAI ({"error":{"message","type","code"}}), Anthropic ({"error":{"type","message"}}),
+// Gemini ({"error":{"code","message","status"}}) and Ollama ({"error":"..."}).
+func parseErrorBody(raw []byte) (code, message string) {
+	var env struct {
+		Error   json.RawMessage `json:"error"`
+		Message string          `json:"message"`
+	}
+	if json.Unmarshal(raw, &env) != nil {
+		return "", ""
+	}
+	if len(env.Error) == 0 {
+		return "", env.Message
+	}
+	var s string
+	if json.Unmarshal(env.Error, &s) == nil {
+		return "", s
+	}
+	var obj struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+		Code    any    `json:"code"`
+		Status  string `json:"status"`
+	}
+	if json.Unmarshal(env.Error, &obj) != nil {
+		return "", ""
+	}
+	return errorCode(obj.Code, obj.Status, obj.Type), obj.Message
+}
+
+// errorCode returns the most specific of a provider's error code candidates.
+// Numeric codes are ignored since they duplicate the HTTP status.
+func errorCode(code any, fallbacks ...string) string {
+	if s, ok := code.(string); ok && s != "" {
+		return s
+	}
+	for _, f := range fallbacks {
+		if f != "" {
+			return f
+		}
+	}
+	return ""
+}
+
+func (c *caller) do(ctx context.Context, method, path string, body []byte) (*http.Response, error) {
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, r)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range c.headers {
+		httpReq.Header.Set(k, v)
+	}
+	c.logDebug("llm: sending request", zap.String("provider", string(c.provider)), zap.String("method", method), zap.String("url", httpReq.URL.String()))
+	httpResp, err := c.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	c.logDebug("llm: received response", zap.String("provider", string(c.provider)), zap.Int("status", httpResp.StatusCode))
+	return httpResp, nil
+}
+
+func (c *caller) logDebug(msg string, fields ...zap.Field) {
+	if c.logger != nil {
+		c.logger.Debug(msg, fields...)
+	}
+}
+
+// parseRetryAfter reads the server-suggested retry delay from the non-standard
+// retry-after-ms header or the standard Retry-After header (seconds or HTTP date).
+func parseRetryAfter(h http.Header, now time.Time) time.Duration {
+	if v := h.Get("retry-after-ms"); v != "" {
+		if ms, err := strconv.ParseFloat(v, 64); err == nil && ms > 0 {
+			return time.Duration(ms * float64(time.Millisecond))
+		}
+	}
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.ParseFloat(v, 64); err == nil {
+		if secs <= 0 {
+			return 0
+		}
+		return time.Duration(secs * float64(time.Second))
+	}
+	if t, err := http.ParseTime(v); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
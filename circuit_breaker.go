@@ -0,0 +1,308 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Default circuit breaker settings used when CircuitBreakerConfig fields are zero.
+const (
+	DefaultCircuitFailureRatio = 0.5
+	DefaultCircuitMinRequests  = 5
+	DefaultCircuitWindow       = time.Minute
+	DefaultCircuitCooldown     = 30 * time.Second
+)
+
+// CircuitState is the state of a circuit for one model.
+type CircuitState int
+
+const (
+	// CircuitClosed lets requests through and counts failures.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects requests with ErrCircuitOpen until the cooldown elapses.
+	CircuitOpen
+	// CircuitHalfOpen lets a limited number of probe requests through.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("CircuitState(%d)", int(s))
+}
+
+// ErrCircuitOpen is returned when a circuit breaker rejects a request for Model.
+type ErrCircuitOpen struct {
+	Model string
+	// RetryAfter is the time left until the circuit half-opens.
+	RetryAfter time.Duration
+}
+
+func (e *ErrCircuitOpen) Error() string {
+	return fmt.Sprintf("llm: circuit open for model %q (retry in %s)", e.Model, e.RetryAfter.Round(time.Millisecond))
+}
+
+// Is supports errors.Is for ErrCircuitOpen.
+func (e *ErrCircuitOpen) Is(target error) bool {
+	t, ok := target.(*ErrCircuitOpen)
+	return ok && (t == nil || t.Model == "" || t.Model == e.Model)
+}
+
+// CircuitBreakerConfig configures NewCircuitBreakerChatProvider.
+type CircuitBreakerConfig struct {
+	// FailureRatio opens the circuit once this share of requests in Window failed (default 0.5).
+	FailureRatio float64
+	// MinRequests is the number of requests in Window before FailureRatio is evaluated (default 5).
+	MinRequests int
+	// Window is the interval over which requests are counted (default 1m).
+	Window time.Duration
+	// Cooldown is how long the circuit stays open before half-opening (default 30s).
+	Cooldown time.Duration
+	// HalfOpenRequests is the number of concurrent probes allowed while half-open (default 1).
+	HalfOpenRequests int
+	// IsFailure decides which errors count against the circuit (default DefaultRetryPolicy,
+	// so invalid requests and cancellations do not trip it).
+	IsFailure func(err error) bool
+	// OnStateChange, if set, is called when the circuit for model changes state.
+	OnStateChange func(model string, from, to CircuitState)
+}
+
+func (c CircuitBreakerConfig) withDefaults() CircuitBreakerConfig {
+	if c.FailureRatio <= 0 || c.FailureRatio > 1 {
+		c.FailureRatio = DefaultCircuitFailureRatio
+	}
+	if c.MinRequests <= 0 {
+		c.MinRequests = DefaultCircuitMinRequests
+	}
+	if c.Window <= 0 {
+		c.Window = DefaultCircuitWindow
+	}
+	if c.Cooldown <= 0 {
+		c.Cooldown = DefaultCircuitCooldown
+	}
+	if c.HalfOpenRequests <= 0 {
+		c.HalfOpenRequests = 1
+	}
+	if c.IsFailure == nil {
+		c.IsFailure = DefaultRetryPolicy
+	}
+	return c
+}
+
+// circuit tracks one model. Guarded by CircuitBreakerChatProvider.mu.
+type circuit struct {
+	state       CircuitState
+	windowStart time.Time
+	total       int
+	failures    int
+	openedAt    time.Time
+	probes      int
+	// gen counts state changes so outcomes of requests admitted under an
+	// earlier state can be told apart and ignored.
+	gen uint64
+}
+
+// CircuitBreakerChatProvider wraps a ChatProvider with a circuit breaker per ChatRequest.Model.
+// A circuit opens when the failure ratio within Window reaches FailureRatio, rejects requests
+// with ErrCircuitOpen for Cooldown, then half-opens to let probe requests decide whether to close.
+type CircuitBreakerChatProvider struct {
+	next ChatProvider
+	cfg  CircuitBreakerConfig
+	now  func() time.Time
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+// NewCircuitBreakerChatProvider wraps p with a per-model circuit breaker.
+func NewCircuitBreakerChatProvider(p ChatProvider, cfg CircuitBreakerConfig) *CircuitBreakerChatProvider {
+	return &CircuitBreakerChatProvider{
+		next:     p,
+		cfg:      cfg.withDefaults(),
+		now:      time.Now,
+		circuits: make(map[string]*circuit),
+	}
+}
+
+// CircuitBreakerChatMiddleware returns a ChatMiddleware that applies NewCircuitBreakerChatProvider.
+func CircuitBreakerChatMiddleware(cfg CircuitBreakerConfig) ChatMiddleware {
+	return func(p ChatProvider) ChatProvider { return NewCircuitBreakerChatProvider(p, cfg) }
+}
+
+// State returns the current state of the circuit for model.
+func (b *CircuitBreakerChatProvider) State(model string) CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, ok := b.circuits[model]
+	if !ok {
+		return CircuitClosed
+	}
+	b.refresh(model, c, b.now())
+	return c.state
+}
+
+// setState changes c to state and notifies OnStateChange. Caller holds mu.
+func (b *CircuitBreakerChatProvider) setState(model string, c *circuit, state CircuitState, now time.Time) {
+	from := c.state
+	c.state = state
+	c.gen++
+	c.total, c.failures, c.probes = 0, 0, 0
+	c.windowStart = now
+	if state == CircuitOpen {
+		c.openedAt = now
+	}
+	if from != state && b.cfg.OnStateChange != nil {
+		b.cfg.OnStateChange(model, from, state)
+	}
+}
+
+// refresh half-opens an expired open circuit and rolls the counting window. Caller holds mu.
+func (b *CircuitBreakerChatProvider) refresh(model string, c *circuit, now time.Time) {
+	switch c.state {
+	case CircuitOpen:
+		if now.Sub(c.openedAt) >= b.cfg.Cooldown {
+			b.setState(model, c, CircuitHalfOpen, now)
+		}
+	case CircuitClosed:
+		if now.Sub(c.windowStart) >= b.cfg.Window {
+			c.windowStart, c.total, c.failures = now, 0, 0
+		}
+	}
+}
+
+// allow reserves a slot for a request to model and returns the circuit generation
+// it was admitted under, or returns ErrCircuitOpen.
+func (b *CircuitBreakerChatProvider) allow(model string) (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.now()
+	c, ok := b.circuits[model]
+	if !ok {
+		c = &circuit{windowStart: now}
+		b.circuits[model] = c
+	}
+	b.refresh(model, c, now)
+	switch c.state {
+	case CircuitOpen:
+		return 0, &ErrCircuitOpen{Model: model, RetryAfter: b.cfg.Cooldown - now.Sub(c.openedAt)}
+	case CircuitHalfOpen:
+		if c.probes >= b.cfg.HalfOpenRequests {
+			return 0, &ErrCircuitOpen{Model: model}
+		}
+		c.probes++
+	}
+	return c.gen, nil
+}
+
+// record reports the outcome of a request allowed by allow under generation gen.
+// Outcomes from an earlier generation are ignored: a slow request admitted while
+// closed must not act as a half-open probe.
+func (b *CircuitBreakerChatProvider) record(model string, gen uint64, err error) {
+	failed := err != nil && b.cfg.IsFailure(err)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.circuits[model]
+	if c.gen != gen {
+		return
+	}
+	now := b.now()
+	switch c.state {
+	case CircuitHalfOpen:
+		if failed {
+			b.setState(model, c, CircuitOpen, now)
+		} else if err == nil {
+			b.setState(model, c, CircuitClosed, now)
+		} else {
+			c.probes--
+		}
+	case CircuitClosed:
+		b.refresh(model, c, now)
+		c.total++
+		if failed {
+			c.failures++
+		}
+		if c.total >= b.cfg.MinRequests && float64(c.failures) >= b.cfg.FailureRatio*float64(c.total) {
+			b.setState(model, c, CircuitOpen, now)
+		}
+	}
+}
+
+func (b *CircuitBreakerChatProvider) Create(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	model := requestModel(req)
+	gen, err := b.allow(model)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := b.next.Create(ctx, req)
+	b.record(model, gen, err)
+	return resp, err
+}
+
+// CreateStream counts a stream as failed if it fails to open or ends with an error,
+// and as successful once it reaches io.EOF or is closed after delivering a chunk.
+func (b *CircuitBreakerChatProvider) CreateStream(ctx context.Context, req *ChatRequest) (StreamReader, error) {
+	model := requestModel(req)
+	gen, err := b.allow(model)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := b.next.CreateStream(ctx, req)
+	if err != nil {
+		b.record(model, gen, err)
+		return nil, err
+	}
+	return &circuitStream{StreamReader: stream, b: b, model: model, gen: gen}, nil
+}
+
+func requestModel(req *ChatRequest) string {
+	if req == nil {
+		return ""
+	}
+	return req.Model
+}
+
+// circuitStream records the outcome of a stream exactly once.
+type circuitStream struct {
+	StreamReader
+	b        *CircuitBreakerChatProvider
+	model    string
+	gen      uint64
+	received bool
+	once     sync.Once
+}
+
+func (s *circuitStream) done(err error) {
+	s.once.Do(func() { s.b.record(s.model, s.gen, err) })
+}
+
+func (s *circuitStream) Next() (*StreamChunk, error) {
+	chunk, err := s.StreamReader.Next()
+	switch {
+	case errors.Is(err, io.EOF):
+		s.done(nil)
+	case err != nil:
+		s.done(err)
+	case chunk != nil:
+		s.received = true
+	}
+	return chunk, err
+}
+
+func (s *circuitStream) Close() error {
+	if s.received {
+		s.done(nil)
+	} else {
+		s.done(context.Canceled)
+	}
+	return s.StreamReader.Close()
+}
@@ -0,0 +1,173 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestBreaker(p ChatProvider, cfg CircuitBreakerConfig) (*CircuitBreakerChatProvider, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	b := NewCircuitBreakerChatProvider(p, cfg)
+	b.now = clock.now
+	return b, clock
+}
+
+func TestCircuitBreaker_OpensAndRecovers(t *testing.T) {
+	var fail bool
+	mock := &MockChatProvider{CreateFunc: func(context.Context, *ChatRequest) (*ChatResponse, error) {
+		if fail {
+			return nil, errTransient
+		}
+		return &ChatResponse{}, nil
+	}}
+	var transitions []CircuitState
+	b, clock := newTestBreaker(mock, CircuitBreakerConfig{
+		MinRequests: 2,
+		Cooldown:    time.Second,
+		OnStateChange: func(model string, from, to CircuitState) {
+			transitions = append(transitions, to)
+		},
+	})
+	ctx := context.Background()
+	req := &ChatRequest{Model: "m"}
+
+	fail = true
+	b.Create(ctx, req)
+	b.Create(ctx, req)
+	if b.State("m") != CircuitOpen {
+		t.Fatalf("State = %v, want open", b.State("m"))
+	}
+	_, err := b.Create(ctx, req)
+	var open *ErrCircuitOpen
+	if !errors.As(err, &open) || open.Model != "m" || open.RetryAfter != time.Second {
+		t.Fatalf("err = %v, want ErrCircuitOpen", err)
+	}
+	if b.State("other") != CircuitClosed {
+		t.Error("circuits should be tracked per model")
+	}
+
+	clock.advance(time.Second)
+	if b.State("m") != CircuitHalfOpen {
+		t.Fatalf("State = %v, want half-open", b.State("m"))
+	}
+	fail = false
+	if _, err := b.Create(ctx, req); err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+	if b.State("m") != CircuitClosed {
+		t.Errorf("State = %v, want closed", b.State("m"))
+	}
+	want := []CircuitState{CircuitOpen, CircuitHalfOpen, CircuitClosed}
+	if len(transitions) != len(want) {
+		t.Fatalf("transitions = %v, want %v", transitions, want)
+	}
+	for i := range want {
+		if transitions[i] != want[i] {
+			t.Errorf("transitions = %v, want %v", transitions, want)
+		}
+	}
+}
+
+func TestCircuitBreaker_HalfOpenProbeFails(t *testing.T) {
+	b, clock := newTestBreaker(modelEcho(errTransient), CircuitBreakerConfig{MinRequests: 1, Cooldown: time.Second})
+	ctx := context.Background()
+	b.Create(ctx, &ChatRequest{Model: "m"})
+	clock.advance(time.Second)
+	b.Create(ctx, &ChatRequest{Model: "m"})
+	if b.State("m") != CircuitOpen {
+		t.Errorf("State = %v, want open after failed probe", b.State("m"))
+	}
+}
+
+func TestCircuitBreaker_IgnoresNonFailures(t *testing.T) {
+	bad := &APIError{Provider: "test", StatusCode: http.StatusBadRequest}
+	b, _ := newTestBreaker(modelEcho(bad), CircuitBreakerConfig{MinRequests: 1})
+	for range 3 {
+		b.Create(context.Background(), &ChatRequest{Model: "m"})
+	}
+	if b.State("m") != CircuitClosed {
+		t.Errorf("State = %v, want closed for client errors", b.State("m"))
+	}
+}
+
+func TestCircuitBreaker_WindowResets(t *testing.T) {
+	b, clock := newTestBreaker(modelEcho(errTransient), CircuitBreakerConfig{MinRequests: 2, Window: time.Second})
+	b.Create(context.Background(), &ChatRequest{Model: "m"})
+	clock.advance(time.Second)
+	b.Create(context.Background(), &ChatRequest{Model: "m"})
+	if b.State("m") != CircuitClosed {
+		t.Errorf("State = %v, want closed once the window rolled", b.State("m"))
+	}
+}
+
+func TestCircuitBreaker_Stream(t *testing.T) {
+	mock := &MockChatProvider{CreateStreamFunc: func(context.Context, *ChatRequest) (StreamReader, error) {
+		return &sliceStream{err: errTransient}, nil
+	}}
+	b, _ := newTestBreaker(mock, CircuitBreakerConfig{MinRequests: 1})
+	stream, err := b.CreateStream(context.Background(), &ChatRequest{Model: "m"})
+	if err != nil {
+		t.Fatalf("CreateStream: %v", err)
+	}
+	if _, err := stream.Next(); err == nil || errors.Is(err, io.EOF) {
+		t.Fatalf("Next err = %v", err)
+	}
+	stream.Close()
+	if _, err := b.CreateStream(context.Background(), &ChatRequest{Model: "m"}); !errors.Is(err, &ErrCircuitOpen{}) {
+		t.Errorf("err = %v, want ErrCircuitOpen", err)
+	}
+}
+
+func TestCircuitBreaker_IgnoresStaleOutcomes(t *testing.T) {
+	mock := &MockChatProvider{
+		CreateFunc: func(context.Context, *ChatRequest) (*ChatResponse, error) { return nil, errTransient },
+		CreateStreamFunc: func(context.Context, *ChatRequest) (StreamReader, error) {
+			return &sliceStream{chunks: []*StreamChunk{{}}}, nil
+		},
+	}
+	b, clock := newTestBreaker(mock, CircuitBreakerConfig{MinRequests: 1, Cooldown: time.Second})
+	ctx := context.Background()
+	req := &ChatRequest{Model: "m"}
+
+	// Two streams admitted while closed outlive the circuit opening and half-opening.
+	succeeded, _ := b.CreateStream(ctx, req)
+	canceled, _ := b.CreateStream(ctx, req)
+	b.Create(ctx, req)
+	clock.advance(time.Second)
+	if b.State("m") != CircuitHalfOpen {
+		t.Fatalf("State = %v, want half-open", b.State("m"))
+	}
+
+	succeeded.Next()
+	succeeded.Close()
+	canceled.Close()
+	if b.State("m") != CircuitHalfOpen {
+		t.Fatalf("State = %v, want half-open after stale outcomes", b.State("m"))
+	}
+	if _, err := b.CreateStream(ctx, req); err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+	if _, err := b.CreateStream(ctx, req); !errors.Is(err, &ErrCircuitOpen{}) {
+		t.Errorf("second probe err = %v, want ErrCircuitOpen", err)
+	}
+}
+
+func TestFallbackChat_CircuitOpen(t *testing.T) {
+	f := NewFallbackChatProvider(
+		FallbackTarget{Provider: modelEcho(&ErrCircuitOpen{Model: "a"})},
+		FallbackTarget{Provider: modelEcho(nil), Model: "b"},
+	)
+	resp, err := f.Create(context.Background(), &ChatRequest{})
+	if err != nil || resp.Model != "b" {
+		t.Fatalf("Create = %+v, %v", resp, err)
+	}
+}
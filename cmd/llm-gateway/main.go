@@ -0,0 +1,111 @@
+// Command llm-gateway serves an OpenAI-compatible API (/v1/chat/completions, /v1/embeddings,
+// /v1/models) backed by one llm provider.
+//
+// Usage:
+//
+//	llm-gateway -provider anthropic -models claude-sonnet-4-20250514 -callers callers.json
+//
+// The upstream API key is taken from LLM_API_KEY or the provider's usual environment
+// variable (OPENAI_API_KEY, ANTHROPIC_API_KEY, ...). The callers file maps gateway API keys
+// to callers, each with one quota shared across all models:
+//
+//	{"sk-team-a": {"name": "team-a", "requests_per_minute": 60, "tokens_per_minute": 100000}}
+//
+// Without -callers the gateway accepts unauthenticated requests.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/MetaDiv-AI/llm"
+	"github.com/MetaDiv-AI/llm/gateway"
+	"github.com/MetaDiv-AI/logger"
+	"go.uber.org/zap"
+)
+
+type callerFile map[string]struct {
+	Name              string `json:"name"`
+	RequestsPerMinute int    `json:"requests_per_minute"`
+	TokensPerMinute   int    `json:"tokens_per_minute"`
+}
+
+func main() {
+	addr := flag.String("addr", ":8080", "listen address")
+	provider := flag.String("provider", string(llm.ProviderOpenRouter), "upstream provider ("+strings.Join(providerNames(), ", ")+")")
+	baseURL := flag.String("base-url", "", "upstream base URL (required for openai-compatible)")
+	models := flag.String("models", "", "comma-separated models listed by /v1/models")
+	callersPath := flag.String("callers", "", "JSON file mapping gateway API keys to callers and quotas")
+	timeout := flag.Duration("timeout", 5*time.Minute, "upstream HTTP timeout")
+	flag.Parse()
+
+	log := logger.New().Production().Build()
+	defer log.Sync()
+
+	if err := run(log, *addr, llm.Provider(*provider), *baseURL, *models, *callersPath, *timeout); err != nil {
+		log.Error("llm-gateway: exiting", zap.Error(err))
+		os.Exit(1)
+	}
+}
+
+func run(log logger.Logger, addr string, provider llm.Provider, baseURL, models, callersPath string, timeout time.Duration) error {
+	opts := []llm.Option{llm.WithTimeout(timeout), llm.WithLogger(log)}
+	if key := os.Getenv("LLM_API_KEY"); key != "" {
+		opts = append(opts, llm.WithAPIKey(key))
+	}
+	if baseURL != "" {
+		opts = append(opts, llm.WithBaseURL(baseURL))
+	}
+	client, err := llm.NewClient(provider, opts...)
+	if err != nil {
+		return err
+	}
+
+	callers, err := loadCallers(callersPath)
+	if err != nil {
+		return err
+	}
+	cfg := gateway.Config{Client: client, Callers: callers, Logger: log}
+	if models != "" {
+		cfg.Models = strings.Split(models, ",")
+	}
+
+	log.Info("llm-gateway: listening", zap.String("addr", addr), zap.String("provider", string(provider)), zap.Int("callers", len(callers)))
+	srv := &http.Server{Addr: addr, Handler: gateway.New(cfg), ReadHeaderTimeout: 10 * time.Second}
+	return srv.ListenAndServe()
+}
+
+func loadCallers(path string) (map[string]gateway.Caller, error) {
+	if path == "" {
+		return nil, nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file callerFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	callers := make(map[string]gateway.Caller, len(file))
+	for key, c := range file {
+		callers[key] = gateway.Caller{
+			Name:  c.Name,
+			Quota: llm.RateLimitConfig{RequestsPerMinute: c.RequestsPerMinute, TokensPerMinute: c.TokensPerMinute},
+		}
+	}
+	return callers, nil
+}
+
+func providerNames() []string {
+	var names []string
+	for _, p := range llm.Providers() {
+		names = append(names, string(p))
+	}
+	return names
+}
@@ -0,0 +1,94 @@
+// Command llm-mcp is an MCP server (stdio transport) exposing a "chat" tool backed by one llm
+// provider, plus curated prompts, so editors and agents can use them through MCP.
+//
+// Usage:
+//
+//	llm-mcp -provider anthropic -model claude-sonnet-4-20250514 -prompts prompts.json
+//
+// The upstream API key is taken from LLM_API_KEY or the provider's usual environment
+// variable (OPENAI_API_KEY, ANTHROPIC_API_KEY, ...). The prompts file is a JSON array of
+// mcp.Prompt values:
+//
+//	[{"name": "review", "description": "Code review", "arguments": [{"name": "lang", "required": true}],
+//	  "messages": [{"role": "system", "content": "You review {{lang}} code."}]}]
+//
+// Logs are written to stderr; stdout carries the protocol.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/MetaDiv-AI/llm"
+	"github.com/MetaDiv-AI/llm/mcp"
+	"github.com/MetaDiv-AI/logger"
+	"go.uber.org/zap"
+)
+
+func main() {
+	provider := flag.String("provider", string(llm.ProviderOpenRouter), "upstream provider ("+strings.Join(providerNames(), ", ")+")")
+	baseURL := flag.String("base-url", "", "upstream base URL (required for openai-compatible)")
+	model := flag.String("model", "", "default model for the chat tool")
+	models := flag.String("models", "", "comma-separated models callers may choose from")
+	promptsPath := flag.String("prompts", "", "JSON file with curated prompts")
+	instructions := flag.String("instructions", "", "instructions sent to clients during initialization")
+	timeout := flag.Duration("timeout", 5*time.Minute, "upstream HTTP timeout")
+	flag.Parse()
+
+	log := logger.New().Production().Output(os.Stderr).Build()
+	defer log.Sync()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	cfg := mcp.ServerConfig{Model: *model, Instructions: *instructions, Logger: log}
+	if *models != "" {
+		cfg.Models = strings.Split(*models, ",")
+	}
+	if err := run(ctx, log, cfg, llm.Provider(*provider), *baseURL, *promptsPath, *timeout); err != nil {
+		log.Error("llm-mcp: exiting", zap.Error(err))
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, log logger.Logger, cfg mcp.ServerConfig, provider llm.Provider, baseURL, promptsPath string, timeout time.Duration) error {
+	opts := []llm.Option{llm.WithTimeout(timeout), llm.WithLogger(log)}
+	if key := os.Getenv("LLM_API_KEY"); key != "" {
+		opts = append(opts, llm.WithAPIKey(key))
+	}
+	if baseURL != "" {
+		opts = append(opts, llm.WithBaseURL(baseURL))
+	}
+	client, err := llm.NewClient(provider, opts...)
+	if err != nil {
+		return err
+	}
+	cfg.Client = client
+
+	if promptsPath != "" {
+		raw, err := os.ReadFile(promptsPath)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(raw, &cfg.Prompts); err != nil {
+			return fmt.Errorf("parse %s: %w", promptsPath, err)
+		}
+	}
+
+	log.Info("llm-mcp: serving on stdio", zap.String("provider", string(provider)), zap.Int("prompts", len(cfg.Prompts)))
+	return mcp.NewServer(cfg).ServeStdio(ctx)
+}
+
+func providerNames() []string {
+	var names []string
+	for _, p := range llm.Providers() {
+		names = append(names, string(p))
+	}
+	return names
+}
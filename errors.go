@@ -3,6 +3,9 @@ package llm
 import (
 	"errors"
 	"fmt"
+	"net/http"
+	"strings"
+	"time"
 )
 
 // ErrUnknownProvider is returned when the provider is not supported.
@@ -23,6 +26,15 @@ func (e *ErrUnknownProvider) Is(target error) bool {
 // ErrInvalidRequest is returned when a request fails validation.
 var ErrInvalidRequest = errors.New("llm: invalid request")
 
+// ErrUnsupported is returned when a provider does not support the requested operation or input.
+var ErrUnsupported = errors.New("llm: not supported by provider")
+
+// ErrMissingAPIKey is returned by NewClient when a provider requires an API key and none is configured.
+var ErrMissingAPIKey = errors.New("llm: missing API key")
+
+// ErrMissingBaseURL is returned by NewClient when a provider requires WithBaseURL and none is configured.
+var ErrMissingBaseURL = errors.New("llm: missing base URL")
+
 // ValidationError represents a validation failure with field and message.
 type ValidationError struct {
 	Field   string
@@ -41,3 +53,109 @@ func (e *ValidationError) Is(target error) bool {
 	t, ok := target.(*ValidationError)
 	return ok && (t == nil || (t.Field == e.Field && t.Message == e.Message))
 }
+
+// Error categories matched by errors.Is against an *APIError.
+var (
+	ErrRateLimited           = errors.New("llm: rate limited")
+	ErrAuthentication        = errors.New("llm: authentication failed")
+	ErrContextLengthExceeded = errors.New("llm: context length exceeded")
+	ErrContentFiltered       = errors.New("llm: content filtered")
+	ErrProviderUnavailable   = errors.New("llm: provider unavailable")
+	ErrInsufficientCredits   = errors.New("llm: insufficient credits")
+)
+
+// APIError is an error reported by a provider, either as a non-2xx HTTP response or as an
+// error event inside a stream (StatusCode 0). Use errors.Is with the category sentinels
+// (ErrRateLimited, ErrAuthentication, ...) to branch on the failure type.
+type APIError struct {
+	Provider   Provider
+	StatusCode int
+	// Code is the provider's error code or type (e.g. "rate_limit_error", "context_length_exceeded").
+	Code       string
+	Message    string
+	RequestID  string
+	RetryAfter time.Duration // from Retry-After / retry-after-ms; 0 if absent
+	// Body is the raw response body.
+	Body string
+	// Err is the underlying backend error, if any.
+	Err error
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = strings.TrimSpace(e.Body)
+	}
+	if e.StatusCode == 0 {
+		return fmt.Sprintf("llm: %s: %s", e.Provider, msg)
+	}
+	return fmt.Sprintf("llm: %s: HTTP %d: %s", e.Provider, e.StatusCode, msg)
+}
+
+// Unwrap returns the underlying backend error.
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is the category of e.
+func (e *APIError) Is(target error) bool {
+	c := e.Category()
+	return c != nil && target == c
+}
+
+// Category returns the sentinel describing e (ErrRateLimited, ErrAuthentication,
+// ErrContextLengthExceeded, ErrContentFiltered, ErrProviderUnavailable,
+// ErrInsufficientCredits or ErrInvalidRequest), or nil when the error fits none of them.
+func (e *APIError) Category() error {
+	code := strings.ToLower(e.Code)
+	text := strings.ToLower(e.Message + " " + e.Body)
+	switch {
+	case code == "context_length_exceeded" || e.StatusCode == http.StatusRequestEntityTooLarge ||
+		containsAny(text, "maximum context length", "context length", "context window", "prompt is too long", "too many tokens"):
+		return ErrContextLengthExceeded
+	case containsAny(code, "content_filter", "content_policy", "safety", "moderation") ||
+		containsAny(text, "content_filter", "content management policy", "content_policy_violation", "flagged"):
+		return ErrContentFiltered
+	case code == "insufficient_quota" || e.StatusCode == http.StatusPaymentRequired ||
+		containsAny(text, "insufficient credits", "credit balance", "exceeded your current quota"):
+		return ErrInsufficientCredits
+	case strings.Contains(code, "invalid_request"):
+		return ErrInvalidRequest
+	case e.StatusCode == http.StatusTooManyRequests || containsAny(code, "rate_limit", "resource_exhausted"):
+		return ErrRateLimited
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden ||
+		containsAny(code, "authentication", "permission", "unauthenticated", "invalid_api_key"):
+		return ErrAuthentication
+	case e.StatusCode >= 500 || containsAny(code, "overloaded", "unavailable", "server_error", "internal"):
+		return ErrProviderUnavailable
+	}
+	return nil
+}
+
+// retryable reports whether the request may succeed if sent again.
+func (e *APIError) retryable() bool {
+	// Billing, auth and request errors fail the same way however often they are sent,
+	// even when reported with a retryable status such as 429.
+	c := e.Category()
+	switch c {
+	case ErrInsufficientCredits, ErrAuthentication, ErrInvalidRequest:
+		return false
+	}
+	switch e.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests,
+		http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout,
+		529: // Anthropic "overloaded"
+		return true
+	}
+	return c == ErrRateLimited || c == ErrProviderUnavailable
+}
+
+func containsAny(s string, substrs ...string) bool {
+	for _, sub := range substrs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
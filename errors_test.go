@@ -1,8 +1,16 @@
 package llm
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
+
+	oerrors "github.com/MetaDiv-AI/openrouter/errors"
 )
 
 func TestErrUnknownProvider_Is(t *testing.T) {
@@ -17,3 +25,117 @@ func TestErrUnknownProvider_Is(t *testing.T) {
 		t.Error("errors.Is should not match different provider")
 	}
 }
+
+func TestAPIError_Category(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *APIError
+		want error
+	}{
+		{"429", &APIError{StatusCode: 429}, ErrRateLimited},
+		{"anthropic rate limit", &APIError{Code: "rate_limit_error"}, ErrRateLimited},
+		{"quota", &APIError{StatusCode: 429, Code: "insufficient_quota"}, ErrInsufficientCredits},
+		{"402", &APIError{StatusCode: 402}, ErrInsufficientCredits},
+		{"401", &APIError{StatusCode: 401}, ErrAuthentication},
+		{"context code", &APIError{StatusCode: 400, Code: "context_length_exceeded"}, ErrContextLengthExceeded},
+		{"context message", &APIError{StatusCode: 400, Message: "prompt is too long: 210000 tokens > 200000 maximum"}, ErrContextLengthExceeded},
+		{"content filter", &APIError{StatusCode: 400, Code: "content_filter"}, ErrContentFiltered},
+		{"moderation", &APIError{StatusCode: 403, Code: "moderation"}, ErrContentFiltered},
+		{"overloaded", &APIError{StatusCode: 529, Code: "overloaded_error"}, ErrProviderUnavailable},
+		{"stream overloaded", &APIError{Code: "overloaded_error"}, ErrProviderUnavailable},
+		{"invalid request code", &APIError{StatusCode: 400, Code: "invalid_request_error"}, ErrInvalidRequest},
+		{"bad request", &APIError{StatusCode: 400, Message: "invalid model"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Category(); got != tt.want {
+				t.Errorf("Category() = %v, want %v", got, tt.want)
+			}
+			if tt.want != nil && !errors.Is(fmt.Errorf("wrapped: %w", tt.err), tt.want) {
+				t.Errorf("errors.Is(%v) = false", tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIError_Retryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *APIError
+		want bool
+	}{
+		{"429", &APIError{StatusCode: 429}, true},
+		{"503", &APIError{StatusCode: 503}, true},
+		{"overloaded", &APIError{Code: "overloaded_error"}, true},
+		{"429 insufficient quota", &APIError{StatusCode: 429, Code: "insufficient_quota"}, false},
+		{"429 quota message", &APIError{StatusCode: 429, Message: "You exceeded your current quota"}, false},
+		{"429 invalid request", &APIError{StatusCode: 429, Code: "invalid_request_error"}, false},
+		{"401", &APIError{StatusCode: 401}, false},
+		{"400", &APIError{StatusCode: 400}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DefaultRetryPolicy(tt.err); got != tt.want {
+				t.Errorf("DefaultRetryPolicy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIError_FromResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Request-Id", "req_123")
+		w.Header().Set("Retry-After", "7")
+		w.WriteHeader(http.StatusTooManyRequests)
+		io.WriteString(w, `{"type":"error","error":{"type":"rate_limit_error","message":"slow down"}}`)
+	}))
+	defer srv.Close()
+	client, err := NewClient(ProviderAnthropic, WithAPIKey("k"), WithBaseURL(srv.URL), WithMaxRetries(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Chat.Create(context.Background(), &ChatRequest{Model: "m", Messages: []Message{{Role: "user", Content: "hi"}}})
+	var ae *APIError
+	if !errors.As(err, &ae) {
+		t.Fatalf("err = %v, want *APIError", err)
+	}
+	if ae.Provider != ProviderAnthropic || ae.StatusCode != 429 || ae.Code != "rate_limit_error" ||
+		ae.Message != "slow down" || ae.RequestID != "req_123" || ae.RetryAfter != 7*time.Second {
+		t.Errorf("APIError = %+v", ae)
+	}
+	if !errors.Is(err, ErrRateLimited) {
+		t.Error("errors.Is(err, ErrRateLimited) = false")
+	}
+}
+
+func TestParseErrorBody(t *testing.T) {
+	tests := []struct {
+		body, code, message string
+	}{
+		{`{"error":{"message":"m","type":"invalid_request_error","code":"context_length_exceeded"}}`, "context_length_exceeded", "m"},
+		{`{"error":{"code":429,"message":"m","status":"RESOURCE_EXHAUSTED"}}`, "RESOURCE_EXHAUSTED", "m"},
+		{`{"error":"model not found"}`, "", "model not found"},
+		{`not json`, "", ""},
+	}
+	for _, tt := range tests {
+		code, message := parseErrorBody([]byte(tt.body))
+		if code != tt.code || message != tt.message {
+			t.Errorf("parseErrorBody(%s) = %q, %q; want %q, %q", tt.body, code, message, tt.code, tt.message)
+		}
+	}
+}
+
+func TestToORAPIError(t *testing.T) {
+	orErr := &oerrors.OpenRouterError{HTTPStatus: 403, Code: 403, Message: "flagged"}
+	err := toORAPIError(orErr)
+	if !errors.Is(err, ErrContentFiltered) {
+		t.Errorf("err = %v, want ErrContentFiltered", err)
+	}
+	var target *oerrors.OpenRouterError
+	if !errors.As(err, &target) || target != orErr {
+		t.Error("APIError should unwrap to the OpenRouterError")
+	}
+	if !errors.Is(toORAPIError(&oerrors.OpenRouterError{Code: 402}), ErrInsufficientCredits) {
+		t.Error("402 should map to ErrInsufficientCredits")
+	}
+}
@@ -0,0 +1,211 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// FallbackTarget is one entry in a FallbackChatProvider chain.
+type FallbackTarget struct {
+	// Name labels the target in FallbackInfo (e.g. "anthropic"). Optional.
+	Name string
+	// Provider serves requests for this target.
+	Provider ChatProvider
+	// Model replaces ChatRequest.Model when non-empty.
+	Model string
+}
+
+// FallbackInfo records which FallbackChatProvider target served a response.
+type FallbackInfo struct {
+	// Index is the position of the serving target in the chain.
+	Index int
+	// Name and Model are copied from the serving target.
+	Name  string
+	Model string
+	// Errors holds the errors of the targets tried before it, in order.
+	Errors []error
+}
+
+// FallbackError is returned when every FallbackChatProvider target failed.
+// It unwraps to each target's error, so errors.Is and errors.As see all of them.
+type FallbackError struct {
+	Errors []error
+}
+
+func (e *FallbackError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("llm: all %d fallback targets failed: %s", len(e.Errors), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the per-target errors.
+func (e *FallbackError) Unwrap() []error {
+	return e.Errors
+}
+
+// DefaultFallbackPolicy falls back on anything DefaultRetryPolicy retries (rate limits,
+// timeouts, overloads, network failures) plus context-length and content-filter rejections,
+// which another model may accept, and ErrCircuitOpen.
+func DefaultFallbackPolicy(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, &ErrCircuitOpen{}) || errors.Is(err, ErrRateLimited) ||
+		errors.Is(err, ErrContextLengthExceeded) || errors.Is(err, ErrContentFiltered) ||
+		errors.Is(err, ErrProviderUnavailable) {
+		return true
+	}
+	return DefaultRetryPolicy(err)
+}
+
+// FallbackChatProvider tries Targets in order, moving to the next target when one fails
+// with an error accepted by Policy. Responses carry a FallbackInfo naming the serving target.
+// Streams fall back only while no chunk has been delivered to the caller.
+type FallbackChatProvider struct {
+	Targets []FallbackTarget
+	// Policy decides which errors move on to the next target (default DefaultFallbackPolicy).
+	// Other errors are returned immediately.
+	Policy RetryPolicy
+	// OnFallback, if set, is called when target index fails with err and the next target is tried.
+	OnFallback func(index int, err error)
+}
+
+// NewFallbackChatProvider returns a FallbackChatProvider over targets using DefaultFallbackPolicy.
+func NewFallbackChatProvider(targets ...FallbackTarget) *FallbackChatProvider {
+	return &FallbackChatProvider{Targets: targets}
+}
+
+func (f *FallbackChatProvider) policy() RetryPolicy {
+	if f.Policy != nil {
+		return f.Policy
+	}
+	return DefaultFallbackPolicy
+}
+
+// request returns req with the model of target i applied.
+func (f *FallbackChatProvider) request(i int, req *ChatRequest) *ChatRequest {
+	if f.Targets[i].Model == "" || req == nil {
+		return req
+	}
+	r := *req
+	r.Model = f.Targets[i].Model
+	return &r
+}
+
+// next records err for target i and reports whether the following target should be tried.
+func (f *FallbackChatProvider) next(ctx context.Context, i int, err error, errs *[]error) bool {
+	*errs = append(*errs, err)
+	if i+1 >= len(f.Targets) || ctx.Err() != nil || !f.policy()(err) {
+		return false
+	}
+	if f.OnFallback != nil {
+		f.OnFallback(i, err)
+	}
+	return true
+}
+
+// fallbackErr returns the error to surface after errs: the sole error when only one target
+// was tried, otherwise a FallbackError.
+func fallbackErr(errs []error) error {
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return &FallbackError{Errors: slices.Clone(errs)}
+}
+
+func (f *FallbackChatProvider) info(i int, errs []error) *FallbackInfo {
+	return &FallbackInfo{Index: i, Name: f.Targets[i].Name, Model: f.Targets[i].Model, Errors: slices.Clone(errs)}
+}
+
+func (f *FallbackChatProvider) Create(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	if len(f.Targets) == 0 {
+		return nil, &ValidationError{Field: "targets", Message: "cannot be empty"}
+	}
+	var errs []error
+	for i := range f.Targets {
+		resp, err := f.Targets[i].Provider.Create(ctx, f.request(i, req))
+		if err == nil {
+			resp.Fallback = f.info(i, errs)
+			return resp, nil
+		}
+		if !f.next(ctx, i, err, &errs) {
+			break
+		}
+	}
+	return nil, fallbackErr(errs)
+}
+
+func (f *FallbackChatProvider) CreateStream(ctx context.Context, req *ChatRequest) (StreamReader, error) {
+	if len(f.Targets) == 0 {
+		return nil, &ValidationError{Field: "targets", Message: "cannot be empty"}
+	}
+	s := &fallbackStream{ctx: ctx, req: req, f: f}
+	if err := s.open(0); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// fallbackStream moves to the next target while no chunk has been delivered yet.
+// cur is nil once the last target has failed and been closed.
+type fallbackStream struct {
+	ctx     context.Context
+	req     *ChatRequest
+	f       *FallbackChatProvider
+	cur     StreamReader
+	index   int
+	errs    []error
+	started bool
+}
+
+// open opens the first target at or after i that does not fail.
+func (s *fallbackStream) open(i int) error {
+	for ; i < len(s.f.Targets); i++ {
+		stream, err := s.f.Targets[i].Provider.CreateStream(s.ctx, s.f.request(i, s.req))
+		if err == nil {
+			s.cur, s.index = stream, i
+			return nil
+		}
+		if !s.f.next(s.ctx, i, err, &s.errs) {
+			break
+		}
+	}
+	return fallbackErr(s.errs)
+}
+
+func (s *fallbackStream) Next() (*StreamChunk, error) {
+	for {
+		if s.cur == nil {
+			return nil, fallbackErr(s.errs)
+		}
+		chunk, err := s.cur.Next()
+		if s.started || err == nil || chunk != nil || errors.Is(err, io.EOF) {
+			if chunk != nil {
+				s.started = true
+				chunk.Fallback = s.f.info(s.index, s.errs)
+			}
+			return chunk, err
+		}
+		s.cur.Close()
+		s.cur = nil
+		if !s.f.next(s.ctx, s.index, err, &s.errs) {
+			return nil, fallbackErr(s.errs)
+		}
+		if oerr := s.open(s.index + 1); oerr != nil {
+			return nil, oerr
+		}
+	}
+}
+
+func (s *fallbackStream) Close() error {
+	if s.cur == nil {
+		return nil
+	}
+	return s.cur.Close()
+}
@@ -0,0 +1,179 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func modelEcho(err error) *MockChatProvider {
+	return &MockChatProvider{CreateFunc: func(_ context.Context, req *ChatRequest) (*ChatResponse, error) {
+		if err != nil {
+			return nil, err
+		}
+		return &ChatResponse{Model: req.Model}, nil
+	}}
+}
+
+func TestFallbackChat_Create(t *testing.T) {
+	var fellBack []int
+	f := NewFallbackChatProvider(
+		FallbackTarget{Name: "primary", Provider: modelEcho(errTransient), Model: "a"},
+		FallbackTarget{Name: "secondary", Provider: modelEcho(nil), Model: "b"},
+	)
+	f.OnFallback = func(i int, err error) { fellBack = append(fellBack, i) }
+	resp, err := f.Create(context.Background(), &ChatRequest{Model: "orig"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if resp.Model != "b" {
+		t.Errorf("Model = %q, want b", resp.Model)
+	}
+	if resp.Fallback == nil || resp.Fallback.Index != 1 || resp.Fallback.Name != "secondary" || len(resp.Fallback.Errors) != 1 {
+		t.Errorf("Fallback = %+v", resp.Fallback)
+	}
+	if len(fellBack) != 1 || fellBack[0] != 0 {
+		t.Errorf("OnFallback calls = %v", fellBack)
+	}
+}
+
+func TestFallbackChat_ContextLength(t *testing.T) {
+	tooLong := &APIError{Provider: "test", StatusCode: http.StatusBadRequest, Code: "context_length_exceeded"}
+	f := NewFallbackChatProvider(
+		FallbackTarget{Provider: modelEcho(tooLong), Model: "small"},
+		FallbackTarget{Provider: modelEcho(nil), Model: "large"},
+	)
+	resp, err := f.Create(context.Background(), &ChatRequest{})
+	if err != nil || resp.Model != "large" {
+		t.Fatalf("Create = %+v, %v", resp, err)
+	}
+}
+
+func TestFallbackChat_NonFallbackError(t *testing.T) {
+	bad := &APIError{Provider: "test", StatusCode: http.StatusUnauthorized}
+	second := &MockChatProvider{CreateFunc: func(context.Context, *ChatRequest) (*ChatResponse, error) {
+		t.Error("second target should not be called")
+		return nil, nil
+	}}
+	f := NewFallbackChatProvider(FallbackTarget{Provider: modelEcho(bad)}, FallbackTarget{Provider: second})
+	if _, err := f.Create(context.Background(), &ChatRequest{}); err != bad {
+		t.Errorf("err = %v, want the first target's error", err)
+	}
+}
+
+func TestFallbackChat_AllFail(t *testing.T) {
+	f := NewFallbackChatProvider(FallbackTarget{Provider: modelEcho(errTransient)}, FallbackTarget{Provider: modelEcho(errTransient)})
+	_, err := f.Create(context.Background(), &ChatRequest{})
+	var fe *FallbackError
+	if !errors.As(err, &fe) || len(fe.Errors) != 2 {
+		t.Fatalf("err = %v, want FallbackError with 2 errors", err)
+	}
+	if !errors.Is(err, errTransient) {
+		t.Error("FallbackError should unwrap to target errors")
+	}
+}
+
+func TestFallbackChat_StreamBeforeFirstChunk(t *testing.T) {
+	failing := &MockChatProvider{CreateStreamFunc: func(context.Context, *ChatRequest) (StreamReader, error) {
+		return &sliceStream{err: errTransient}, nil
+	}}
+	ok := &MockChatProvider{CreateStreamFunc: func(context.Context, *ChatRequest) (StreamReader, error) {
+		return &sliceStream{chunks: []*StreamChunk{textChunk("hi")}}, nil
+	}}
+	stream, err := NewFallbackChatProvider(FallbackTarget{Provider: failing}, FallbackTarget{Name: "ok", Provider: ok}).
+		CreateStream(context.Background(), &ChatRequest{})
+	if err != nil {
+		t.Fatalf("CreateStream: %v", err)
+	}
+	defer stream.Close()
+	chunk, err := stream.Next()
+	if err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	if chunk.Fallback == nil || chunk.Fallback.Name != "ok" {
+		t.Errorf("Fallback = %+v", chunk.Fallback)
+	}
+	if _, err := stream.Next(); !errors.Is(err, io.EOF) {
+		t.Errorf("err = %v, want io.EOF", err)
+	}
+}
+
+// closeCounter counts Close calls on a StreamReader.
+type closeCounter struct {
+	StreamReader
+	closes int
+}
+
+func (c *closeCounter) Close() error {
+	c.closes++
+	return c.StreamReader.Close()
+}
+
+func TestFallbackChat_StreamAllFail(t *testing.T) {
+	var streams []*closeCounter
+	failing := &MockChatProvider{CreateStreamFunc: func(context.Context, *ChatRequest) (StreamReader, error) {
+		s := &closeCounter{StreamReader: &sliceStream{err: errTransient}}
+		streams = append(streams, s)
+		return s, nil
+	}}
+	stream, err := NewFallbackChatProvider(FallbackTarget{Provider: failing}, FallbackTarget{Provider: failing}).
+		CreateStream(context.Background(), &ChatRequest{})
+	if err != nil {
+		t.Fatalf("CreateStream: %v", err)
+	}
+	_, err = stream.Next()
+	var ferr *FallbackError
+	if !errors.As(err, &ferr) || len(ferr.Errors) != 2 {
+		t.Fatalf("err = %v, want FallbackError with 2 errors", err)
+	}
+	if _, err := stream.Next(); !errors.Is(err, errTransient) {
+		t.Errorf("Next after failure err = %v", err)
+	}
+	stream.Close()
+	for i, s := range streams {
+		if s.closes != 1 {
+			t.Errorf("stream %d closed %d times, want 1", i, s.closes)
+		}
+	}
+}
+
+func TestFallbackChat_StreamInfoCopiesErrors(t *testing.T) {
+	failing := &MockChatProvider{CreateStreamFunc: func(context.Context, *ChatRequest) (StreamReader, error) {
+		return &sliceStream{err: errTransient}, nil
+	}}
+	ok := &MockChatProvider{CreateStreamFunc: func(context.Context, *ChatRequest) (StreamReader, error) {
+		return &sliceStream{chunks: []*StreamChunk{textChunk("a"), textChunk("b")}}, nil
+	}}
+	stream, _ := NewFallbackChatProvider(FallbackTarget{Provider: failing}, FallbackTarget{Provider: ok}).
+		CreateStream(context.Background(), &ChatRequest{})
+	defer stream.Close()
+	first, _ := stream.Next()
+	first.Fallback.Errors[0] = nil
+	second, _ := stream.Next()
+	if !errors.Is(second.Fallback.Errors[0], errTransient) {
+		t.Errorf("Errors = %v, want each chunk to hold its own copy", second.Fallback.Errors)
+	}
+}
+
+func TestFallbackChat_StreamAfterFirstChunk(t *testing.T) {
+	failing := &MockChatProvider{CreateStreamFunc: func(context.Context, *ChatRequest) (StreamReader, error) {
+		return &sliceStream{chunks: []*StreamChunk{textChunk("a")}, err: errTransient, failAt: 1}, nil
+	}}
+	second := &MockChatProvider{CreateStreamFunc: func(context.Context, *ChatRequest) (StreamReader, error) {
+		t.Error("second target should not be opened after the first chunk")
+		return &sliceStream{}, nil
+	}}
+	stream, err := NewFallbackChatProvider(FallbackTarget{Provider: failing}, FallbackTarget{Provider: second}).
+		CreateStream(context.Background(), &ChatRequest{})
+	if err != nil {
+		t.Fatalf("CreateStream: %v", err)
+	}
+	if _, err := stream.Next(); err != nil {
+		t.Fatalf("first Next: %v", err)
+	}
+	if _, err := stream.Next(); !errors.Is(err, errTransient) {
+		t.Errorf("err = %v, want transient error", err)
+	}
+}
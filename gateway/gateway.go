@@ -0,0 +1,284 @@
+// Package gateway serves an OpenAI-compatible HTTP API backed by an llm.Client.
+//
+// It exposes /v1/chat/completions (including SSE streaming), /v1/embeddings and /v1/models,
+// so tools written in any language can use the client's keys, middleware and fallback rules
+// through one endpoint. Callers authenticate with their own API keys and may be given
+// per-caller request and token quotas.
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MetaDiv-AI/llm"
+	"github.com/MetaDiv-AI/logger"
+	"go.uber.org/zap"
+)
+
+// DefaultMaxBodyBytes is the default limit on request body size (32 MiB).
+const DefaultMaxBodyBytes = 32 << 20
+
+// Caller is a client of the gateway, identified by its API key.
+type Caller struct {
+	// Name identifies the caller in logs and is sent upstream as ChatRequest.User when unset.
+	Name string
+	// Quota limits the caller across all models. Zero limits are unlimited; FailFast is
+	// always set, so exhausted callers get 429 responses instead of waiting. Key and
+	// PerUser are ignored: the quota is keyed by the caller, not by request fields.
+	Quota llm.RateLimitConfig
+}
+
+// Config configures a Server.
+type Config struct {
+	// Client serves requests. Client.Chat is required; Client.Embeddings may be nil.
+	Client *llm.Client
+	// Models is the list returned by /v1/models. When set, chat and embedding requests
+	// for other models are rejected with 404 model_not_found.
+	Models []string
+	// Callers maps API keys (sent as "Authorization: Bearer <key>") to callers.
+	// When empty, the gateway accepts unauthenticated requests.
+	Callers map[string]Caller
+	// MaxBodyBytes limits request bodies (default DefaultMaxBodyBytes).
+	MaxBodyBytes int64
+	// SSE configures streamed responses.
+	SSE llm.SSEOptions
+	// Logger, if set, logs failed requests.
+	Logger logger.Logger
+}
+
+// Server is an http.Handler serving the OpenAI-compatible API.
+type Server struct {
+	cfg     Config
+	mux     *http.ServeMux
+	callers map[string]*caller
+	anon    *caller
+}
+
+// caller holds a Caller with its quota-limited providers.
+type caller struct {
+	Caller
+	chat       llm.ChatProvider
+	embeddings llm.EmbeddingProvider
+}
+
+// New returns a Server for cfg.
+func New(cfg Config) *Server {
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = DefaultMaxBodyBytes
+	}
+	s := &Server{cfg: cfg, mux: http.NewServeMux(), callers: make(map[string]*caller, len(cfg.Callers))}
+	for key, c := range cfg.Callers {
+		s.callers[key] = s.newCaller(c)
+	}
+	if len(cfg.Callers) == 0 {
+		s.anon = s.newCaller(Caller{})
+	}
+	s.mux.HandleFunc("POST /v1/chat/completions", s.withCaller(s.chatCompletions))
+	s.mux.HandleFunc("POST /v1/embeddings", s.withCaller(s.embeddings))
+	s.mux.HandleFunc("GET /v1/models", s.withCaller(s.models))
+	return s
+}
+
+func (s *Server) newCaller(c Caller) *caller {
+	out := &caller{Caller: c, chat: s.cfg.Client.Chat, embeddings: s.cfg.Client.Embeddings}
+	if c.Quota.RequestsPerMinute > 0 || c.Quota.TokensPerMinute > 0 {
+		q := c.Quota
+		q.FailFast = true
+		q.PerUser = false
+		q.Key = "caller:" + c.Name
+		out.chat = llm.NewRateLimitChatProvider(out.chat, q)
+		if out.embeddings != nil {
+			out.embeddings = llm.NewRateLimitEmbeddingProvider(out.embeddings, q)
+		}
+	}
+	return out
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// withCaller authenticates the request and passes the caller to h.
+func (s *Server) withCaller(h func(http.ResponseWriter, *http.Request, *caller)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c := s.anon
+		if c == nil {
+			key, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if c = s.callers[key]; key == "" || c == nil {
+				writeError(w, http.StatusUnauthorized, "invalid_api_key", "invalid or missing API key")
+				return
+			}
+		}
+		h(w, r, c)
+	}
+}
+
+func (s *Server) chatCompletions(w http.ResponseWriter, r *http.Request, c *caller) {
+	var req llm.ChatRequest
+	if !s.decode(w, r, &req) {
+		return
+	}
+	if !s.allowModel(w, req.Model) {
+		return
+	}
+	if req.User == "" {
+		req.User = c.Name
+	}
+	if req.Stream {
+		stream, err := c.chat.CreateStream(r.Context(), &req)
+		if err != nil {
+			s.fail(w, r, c, err)
+			return
+		}
+		if err := llm.WriteSSE(w, r, stream, s.cfg.SSE); err != nil && r.Context().Err() == nil {
+			s.log(r, c, err)
+		}
+		return
+	}
+	resp, err := c.chat.Create(r.Context(), &req)
+	if err != nil {
+		s.fail(w, r, c, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) embeddings(w http.ResponseWriter, r *http.Request, c *caller) {
+	if c.embeddings == nil {
+		writeError(w, http.StatusNotFound, "not_supported", "embeddings are not available")
+		return
+	}
+	var req llm.EmbeddingRequest
+	if !s.decode(w, r, &req) || !s.allowModel(w, req.Model) {
+		return
+	}
+	resp, err := c.embeddings.Create(r.Context(), &req)
+	if err != nil {
+		s.fail(w, r, c, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, struct {
+		Object string `json:"object"`
+		*llm.EmbeddingResponse
+	}{"list", resp})
+}
+
+type model struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+}
+
+func (s *Server) models(w http.ResponseWriter, r *http.Request, _ *caller) {
+	data := make([]model, len(s.cfg.Models))
+	for i, id := range s.cfg.Models {
+		owner, _, found := strings.Cut(id, "/")
+		if !found {
+			owner = "llm-gateway"
+		}
+		data[i] = model{ID: id, Object: "model", OwnedBy: owner}
+	}
+	writeJSON(w, http.StatusOK, struct {
+		Object string  `json:"object"`
+		Data   []model `json:"data"`
+	}{"list", data})
+}
+
+// allowModel reports whether model is served, writing a 404 response if it is not.
+// Every model is allowed when Config.Models is empty.
+func (s *Server) allowModel(w http.ResponseWriter, model string) bool {
+	if len(s.cfg.Models) == 0 || slices.Contains(s.cfg.Models, model) {
+		return true
+	}
+	writeError(w, http.StatusNotFound, "model_not_found", fmt.Sprintf("model %q is not available", model))
+	return false
+}
+
+// decode reads the JSON request body into v, writing a 400 response on failure.
+func (s *Server) decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, s.cfg.MaxBodyBytes)).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "invalid JSON body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// fail writes err as an OpenAI-style error response.
+func (s *Server) fail(w http.ResponseWriter, r *http.Request, c *caller, err error) {
+	s.log(r, c, err)
+	status, code := errorStatus(err)
+	var rl *llm.ErrRateLimitExceeded
+	var ae *llm.APIError
+	switch {
+	case errors.As(err, &rl):
+		w.Header().Set("Retry-After", strconv.Itoa(int((rl.RetryAfter+time.Second-1)/time.Second)))
+	case errors.As(err, &ae) && ae.RetryAfter > 0:
+		w.Header().Set("Retry-After", strconv.Itoa(int((ae.RetryAfter+time.Second-1)/time.Second)))
+	}
+	writeError(w, status, code, err.Error())
+}
+
+func (s *Server) log(r *http.Request, c *caller, err error) {
+	if s.cfg.Logger != nil {
+		s.cfg.Logger.Warn("gateway: request failed", zap.String("path", r.URL.Path), zap.String("caller", c.Name), zap.Error(err))
+	}
+}
+
+// errorStatus maps err to an HTTP status and OpenAI-style error code.
+func errorStatus(err error) (int, string) {
+	var ae *llm.APIError
+	switch {
+	case errors.Is(err, llm.ErrInvalidRequest):
+		return http.StatusBadRequest, "invalid_request_error"
+	case errors.Is(err, llm.ErrUnsupported):
+		return http.StatusBadRequest, "not_supported"
+	case errors.Is(err, &llm.ErrRateLimitExceeded{}), errors.Is(err, llm.ErrRateLimited):
+		return http.StatusTooManyRequests, "rate_limit_exceeded"
+	case errors.Is(err, llm.ErrContextLengthExceeded):
+		return http.StatusBadRequest, "context_length_exceeded"
+	case errors.Is(err, llm.ErrContentFiltered):
+		return http.StatusBadRequest, "content_filter"
+	case errors.Is(err, llm.ErrInsufficientCredits):
+		return http.StatusPaymentRequired, "insufficient_quota"
+	case errors.Is(err, &llm.ErrCircuitOpen{}), errors.Is(err, llm.ErrProviderUnavailable):
+		return http.StatusServiceUnavailable, "service_unavailable"
+	case errors.Is(err, llm.ErrAuthentication):
+		// Upstream credentials belong to the gateway, not the caller.
+		return http.StatusBadGateway, "upstream_authentication"
+	case errors.As(err, &ae) && ae.StatusCode >= 400 && ae.StatusCode < 500:
+		return ae.StatusCode, ae.Code
+	}
+	return http.StatusBadGateway, "upstream_error"
+}
+
+type errorBody struct {
+	Error errorDetail `json:"error"`
+}
+
+type errorDetail struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	Code    string `json:"code,omitempty"`
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	typ := "invalid_request_error"
+	if status >= 500 {
+		typ = "server_error"
+	}
+	writeJSON(w, status, errorBody{Error: errorDetail{Message: message, Type: typ, Code: code}})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,149 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MetaDiv-AI/llm"
+)
+
+func newTestServer(t *testing.T, cfg Config) *httptest.Server {
+	t.Helper()
+	if cfg.Client == nil {
+		cfg.Client = &llm.Client{Chat: &llm.MockChatProvider{}, Embeddings: &llm.MockEmbeddingProvider{}}
+	}
+	srv := httptest.NewServer(New(cfg))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func post(t *testing.T, url, key, body string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestChatCompletions(t *testing.T) {
+	var got *llm.ChatRequest
+	chat := &llm.MockChatProvider{CreateFunc: func(_ context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+		got = req
+		return &llm.ChatResponse{ID: "c1", Choices: []llm.Choice{{Message: &llm.Message{Role: "assistant", Content: "hi"}}}}, nil
+	}}
+	srv := newTestServer(t, Config{
+		Client:  &llm.Client{Chat: chat},
+		Callers: map[string]Caller{"sk-a": {Name: "team-a"}},
+	})
+
+	resp := post(t, srv.URL+"/v1/chat/completions", "sk-a", `{"model":"m","messages":[{"role":"user","content":"hello"}]}`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+	var out llm.ChatResponse
+	json.NewDecoder(resp.Body).Decode(&out)
+	if out.ID != "c1" || out.Choices[0].Message.Content != "hi" {
+		t.Errorf("response = %+v", out)
+	}
+	if got.Model != "m" || got.User != "team-a" || got.Messages[0].Content != "hello" {
+		t.Errorf("request = %+v", got)
+	}
+
+	if resp := post(t, srv.URL+"/v1/chat/completions", "sk-wrong", `{}`); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("bad key status = %d, want 401", resp.StatusCode)
+	}
+}
+
+func TestChatCompletions_Stream(t *testing.T) {
+	chat := &llm.MockChatProvider{StreamChunks: []*llm.StreamChunk{
+		{Choices: []llm.Choice{{Delta: &llm.Message{Content: "Hel"}}}},
+		{Choices: []llm.Choice{{Delta: &llm.Message{Content: "lo"}}}},
+	}}
+	srv := newTestServer(t, Config{Client: &llm.Client{Chat: chat}})
+	resp := post(t, srv.URL+"/v1/chat/completions", "", `{"model":"m","stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+	body, _ := io.ReadAll(resp.Body)
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if !strings.Contains(string(body), `"content":"Hel"`) || !strings.HasSuffix(string(body), "data: [DONE]\n\n") {
+		t.Errorf("body = %q", body)
+	}
+}
+
+func TestChatCompletions_Errors(t *testing.T) {
+	chat := &llm.MockChatProvider{CreateFunc: func(context.Context, *llm.ChatRequest) (*llm.ChatResponse, error) {
+		return nil, &llm.APIError{Provider: "test", StatusCode: 400, Code: "context_length_exceeded", Message: "too long"}
+	}}
+	srv := newTestServer(t, Config{
+		Client:  &llm.Client{Chat: chat},
+		Callers: map[string]Caller{"sk-a": {Name: "a", Quota: llm.RateLimitConfig{RequestsPerMinute: 1}}},
+	})
+	resp := post(t, srv.URL+"/v1/chat/completions", "sk-a", `{"model":"m","messages":[{"role":"user","content":"hi"}]}`)
+	var body errorBody
+	json.NewDecoder(resp.Body).Decode(&body)
+	if resp.StatusCode != http.StatusBadRequest || body.Error.Code != "context_length_exceeded" {
+		t.Errorf("status = %d, body = %+v", resp.StatusCode, body)
+	}
+
+	resp = post(t, srv.URL+"/v1/chat/completions", "sk-a", `{"model":"m-alias","user":"other","messages":[{"role":"user","content":"hi"}]}`)
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") == "" {
+		t.Errorf("quota status = %d, Retry-After = %q", resp.StatusCode, resp.Header.Get("Retry-After"))
+	}
+
+	if resp := post(t, srv.URL+"/v1/chat/completions", "sk-a", `{not json`); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("bad JSON status = %d", resp.StatusCode)
+	}
+}
+
+func TestEmbeddingsAndModels(t *testing.T) {
+	srv := newTestServer(t, Config{Models: []string{"openai/gpt-4o", "local"}})
+	resp := post(t, srv.URL+"/v1/embeddings", "", `{"model":"local","input":"hi"}`)
+	var emb struct {
+		Object string              `json:"object"`
+		Data   []llm.EmbeddingData `json:"data"`
+	}
+	json.NewDecoder(resp.Body).Decode(&emb)
+	if resp.StatusCode != http.StatusOK || emb.Object != "list" || len(emb.Data) != 1 {
+		t.Errorf("embeddings status = %d, body = %+v", resp.StatusCode, emb)
+	}
+
+	mresp, err := http.Get(srv.URL + "/v1/models")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mresp.Body.Close()
+	var models struct {
+		Data []model `json:"data"`
+	}
+	json.NewDecoder(mresp.Body).Decode(&models)
+	if len(models.Data) != 2 || models.Data[0].OwnedBy != "openai" || models.Data[1].OwnedBy != "llm-gateway" {
+		t.Errorf("models = %+v", models.Data)
+	}
+}
+
+func TestModelsEnforced(t *testing.T) {
+	srv := newTestServer(t, Config{Models: []string{"m"}})
+	var body errorBody
+	resp := post(t, srv.URL+"/v1/chat/completions", "", `{"model":"m-alias","messages":[{"role":"user","content":"hi"}]}`)
+	json.NewDecoder(resp.Body).Decode(&body)
+	if resp.StatusCode != http.StatusNotFound || body.Error.Code != "model_not_found" {
+		t.Errorf("chat status = %d, body = %+v", resp.StatusCode, body)
+	}
+	if resp := post(t, srv.URL+"/v1/embeddings", "", `{"model":"e","input":"hi"}`); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("embeddings status = %d, want 404", resp.StatusCode)
+	}
+	if resp := post(t, srv.URL+"/v1/chat/completions", "", `{"model":"m","messages":[{"role":"user","content":"hi"}]}`); resp.StatusCode != http.StatusOK {
+		t.Errorf("listed model status = %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,681 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"strings"
+)
+
+// DefaultGeminiBaseURL is the default base URL for the Gemini API.
+const DefaultGeminiBaseURL = "https://generativelanguage.googleapis.com/v1beta"
+
+type geminiChat struct {
+	c *caller
+}
+
+type geminiEmbedding struct {
+	c *caller
+}
+
+func newGeminiClient(cfg *config) (*Client, error) {
+	apiKey := cfg.APIKey
+	if apiKey == "" {
+		apiKey = os.Getenv("GEMINI_API_KEY")
+	}
+	if apiKey == "" {
+		apiKey = os.Getenv("GOOGLE_API_KEY")
+	}
+	if apiKey == "" {
+		return nil, fmt.Errorf("%w: set GEMINI_API_KEY or use WithAPIKey()", ErrMissingAPIKey)
+	}
+	c := newCaller(ProviderGemini, cfg, DefaultGeminiBaseURL, map[string]string{
+		"x-goog-api-key": apiKey,
+	})
+	return &Client{
+		Chat:       &geminiChat{c: c},
+		Embeddings: &geminiEmbedding{c: c},
+	}, nil
+}
+
+// geminiModelPath returns the "/models/{model}" path prefix, accepting model names with or without the "models/" prefix.
+func geminiModelPath(model string) string {
+	return "/models/" + url.PathEscape(strings.TrimPrefix(model, "models/"))
+}
+
+func (p *geminiChat) Create(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	if err := validateChatRequest(req); err != nil {
+		return nil, err
+	}
+	gReq, err := toGeminiRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	var resp geminiResponse
+	if err := p.c.doPost(ctx, geminiModelPath(req.Model)+":generateContent", gReq, &resp); err != nil {
+		return nil, err
+	}
+	return resp.toLLM(req.Model), nil
+}
+
+func (p *geminiChat) CreateStream(ctx context.Context, req *ChatRequest) (StreamReader, error) {
+	if err := validateChatRequest(req); err != nil {
+		return nil, err
+	}
+	gReq, err := toGeminiRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := p.c.doStreamPost(ctx, geminiModelPath(req.Model)+":streamGenerateContent?alt=sse", gReq)
+	if err != nil {
+		return nil, err
+	}
+	return &geminiStreamReader{body: body, dec: newSSEDecoder(body), model: req.Model, toolCalls: make(map[int]bool)}, nil
+}
+
+func (p *geminiEmbedding) Create(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error) {
+	if err := validateEmbeddingRequest(req); err != nil {
+		return nil, err
+	}
+	inputs, err := embeddingInputs(req.Input)
+	if err != nil {
+		return nil, err
+	}
+	model := "models/" + strings.TrimPrefix(req.Model, "models/")
+
+	if s, ok := req.Input.(string); ok {
+		var resp geminiEmbedResponse
+		gReq := &geminiEmbedRequest{Content: geminiContent{Parts: []geminiPart{{Text: s}}}}
+		if err := p.c.doPost(ctx, geminiModelPath(req.Model)+":embedContent", gReq, &resp); err != nil {
+			return nil, err
+		}
+		return &EmbeddingResponse{Data: []EmbeddingData{{Object: "embedding", Embedding: resp.Embedding.Values}}}, nil
+	}
+
+	batch := &geminiBatchEmbedRequest{Requests: make([]geminiEmbedRequest, len(inputs))}
+	for i, in := range inputs {
+		batch.Requests[i] = geminiEmbedRequest{Model: model, Content: geminiContent{Parts: []geminiPart{{Text: in}}}}
+	}
+	var resp geminiBatchEmbedResponse
+	if err := p.c.doPost(ctx, geminiModelPath(req.Model)+":batchEmbedContents", batch, &resp); err != nil {
+		return nil, err
+	}
+	out := &EmbeddingResponse{Data: make([]EmbeddingData, len(resp.Embeddings))}
+	for i, e := range resp.Embeddings {
+		out.Data[i] = EmbeddingData{Object: "embedding", Embedding: e.Values, Index: i}
+	}
+	return out, nil
+}
+
+// embeddingInputs normalizes EmbeddingRequest.Input into a list of strings.
+func embeddingInputs(input any) ([]string, error) {
+	switch v := input.(type) {
+	case string:
+		return []string{v}, nil
+	case []string:
+		return v, nil
+	case []any:
+		out := make([]string, len(v))
+		for i, s := range v {
+			str, ok := s.(string)
+			if !ok {
+				return nil, &ValidationError{Field: fmt.Sprintf("input[%d]", i), Message: "must be a string"}
+			}
+			out[i] = str
+		}
+		return out, nil
+	default:
+		return nil, &ValidationError{Field: "input", Message: "must be a string or list of strings"}
+	}
+}
+
+// geminiStreamReader reads a streamGenerateContent SSE stream. Each event is a complete
+// GenerateContentResponse carrying the next parts of the candidate.
+type geminiStreamReader struct {
+	body     io.ReadCloser
+	dec      *sseDecoder
+	done     bool
+	model    string
+	numTools int
+	// toolCalls records the candidates that produced a function call, since the finish
+	// reason may arrive in a later chunk than the call.
+	toolCalls map[int]bool
+}
+
+func (s *geminiStreamReader) Next() (*StreamChunk, error) {
+	if s.done {
+		return nil, io.EOF
+	}
+	for {
+		ev, err := s.dec.Next()
+		if err != nil {
+			if err == io.EOF {
+				s.done = true
+			}
+			return nil, err
+		}
+		var resp geminiResponse
+		if err := json.Unmarshal(ev.Data, &resp); err != nil {
+			return nil, fmt.Errorf("llm: gemini: malformed stream chunk: %w", err)
+		}
+		if resp.Error != nil {
+			return nil, &APIError{Provider: ProviderGemini, Code: resp.Error.Status, Message: resp.Error.Message}
+		}
+		if len(resp.Candidates) == 0 && resp.UsageMetadata == nil {
+			continue
+		}
+		chunk := &StreamChunk{
+			ID:      resp.ResponseID,
+			Object:  "chat.completion.chunk",
+			Model:   resp.model(s.model),
+			Choices: make([]Choice, len(resp.Candidates)),
+		}
+		finished := false
+		for i, cand := range resp.Candidates {
+			msg := cand.Content.toLLM(resp.ResponseID, &s.numTools)
+			if len(msg.ToolCalls) > 0 {
+				s.toolCalls[cand.Index] = true
+			}
+			chunk.Choices[i] = Choice{
+				Index:        cand.Index,
+				Delta:        msg,
+				FinishReason: geminiFinishReason(cand.FinishReason, s.toolCalls[cand.Index]),
+			}
+			finished = finished || cand.FinishReason != ""
+		}
+		if finished || len(resp.Candidates) == 0 {
+			chunk.Usage = resp.UsageMetadata.toLLM()
+		}
+		return chunk, nil
+	}
+}
+
+func (s *geminiStreamReader) Close() error {
+	s.done = true
+	return s.body.Close()
+}
+
+// Gemini API wire format.
+
+type geminiRequest struct {
+	Contents          []geminiContent         `json:"contents"`
+	SystemInstruction *geminiContent          `json:"systemInstruction,omitempty"`
+	Tools             []geminiTool            `json:"tools,omitempty"`
+	ToolConfig        *geminiToolConfig       `json:"toolConfig,omitempty"`
+	GenerationConfig  *geminiGenerationConfig `json:"generationConfig,omitempty"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiPart struct {
+	Text             string                  `json:"text,omitempty"`
+	Thought          bool                    `json:"thought,omitempty"`
+	InlineData       *geminiBlob             `json:"inlineData,omitempty"`
+	FileData         *geminiFileData         `json:"fileData,omitempty"`
+	FunctionCall     *geminiFunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *geminiFunctionResponse `json:"functionResponse,omitempty"`
+}
+
+type geminiBlob struct {
+	MimeType string `json:"mimeType"`
+	Data     string `json:"data"`
+}
+
+type geminiFileData struct {
+	MimeType string `json:"mimeType,omitempty"`
+	FileURI  string `json:"fileUri"`
+}
+
+type geminiFunctionCall struct {
+	ID   string          `json:"id,omitempty"`
+	Name string          `json:"name"`
+	Args json.RawMessage `json:"args,omitempty"`
+}
+
+type geminiFunctionResponse struct {
+	ID       string          `json:"id,omitempty"`
+	Name     string          `json:"name"`
+	Response json.RawMessage `json:"response"`
+}
+
+type geminiTool struct {
+	FunctionDeclarations []geminiFunctionDeclaration `json:"functionDeclarations"`
+}
+
+type geminiFunctionDeclaration struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Parameters  map[string]any `json:"parameters,omitempty"`
+}
+
+type geminiToolConfig struct {
+	FunctionCallingConfig geminiFunctionCallingConfig `json:"functionCallingConfig"`
+}
+
+type geminiFunctionCallingConfig struct {
+	Mode                 string   `json:"mode"`
+	AllowedFunctionNames []string `json:"allowedFunctionNames,omitempty"`
+}
+
+type geminiGenerationConfig struct {
+	Temperature      *float64       `json:"temperature,omitempty"`
+	TopP             *float64       `json:"topP,omitempty"`
+	TopK             *int           `json:"topK,omitempty"`
+	MaxOutputTokens  *int           `json:"maxOutputTokens,omitempty"`
+	StopSequences    []string       `json:"stopSequences,omitempty"`
+	Seed             *int           `json:"seed,omitempty"`
+	PresencePenalty  *float64       `json:"presencePenalty,omitempty"`
+	FrequencyPenalty *float64       `json:"frequencyPenalty,omitempty"`
+	ResponseMimeType string         `json:"responseMimeType,omitempty"`
+	ResponseSchema   map[string]any `json:"responseSchema,omitempty"`
+}
+
+type geminiResponse struct {
+	Candidates    []geminiCandidate    `json:"candidates"`
+	UsageMetadata *geminiUsageMetadata `json:"usageMetadata,omitempty"`
+	ModelVersion  string               `json:"modelVersion,omitempty"`
+	ResponseID    string               `json:"responseId,omitempty"`
+	Error         *geminiError         `json:"error,omitempty"`
+}
+
+type geminiCandidate struct {
+	Index        int           `json:"index"`
+	Content      geminiContent `json:"content"`
+	FinishReason string        `json:"finishReason,omitempty"`
+}
+
+type geminiUsageMetadata struct {
+	PromptTokenCount     int `json:"promptTokenCount"`
+	CandidatesTokenCount int `json:"candidatesTokenCount"`
+	ThoughtsTokenCount   int `json:"thoughtsTokenCount"`
+	TotalTokenCount      int `json:"totalTokenCount"`
+}
+
+type geminiError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Status  string `json:"status"`
+}
+
+type geminiEmbedRequest struct {
+	Model   string        `json:"model,omitempty"`
+	Content geminiContent `json:"content"`
+}
+
+type geminiEmbedResponse struct {
+	Embedding geminiEmbeddingValues `json:"embedding"`
+}
+
+type geminiBatchEmbedRequest struct {
+	Requests []geminiEmbedRequest `json:"requests"`
+}
+
+type geminiBatchEmbedResponse struct {
+	Embeddings []geminiEmbeddingValues `json:"embeddings"`
+}
+
+type geminiEmbeddingValues struct {
+	Values []float64 `json:"values"`
+}
+
+// toGeminiRequest converts req to the generateContent format. System and developer
+// messages become systemInstruction, assistant turns use the "model" role, and tool
+// results are sent as functionResponse parts named after the originating tool call.
+func toGeminiRequest(req *ChatRequest) (*geminiRequest, error) {
+	out := &geminiRequest{}
+	gen := &geminiGenerationConfig{
+		Temperature:      req.Temperature,
+		TopP:             req.TopP,
+		TopK:             req.TopK,
+		MaxOutputTokens:  req.MaxTokens,
+		Seed:             req.Seed,
+		PresencePenalty:  req.PresencePenalty,
+		FrequencyPenalty: req.FrequencyPenalty,
+	}
+	stop, err := stopSequences(req.Stop)
+	if err != nil {
+		return nil, err
+	}
+	gen.StopSequences = stop
+	if rf := req.ResponseFormat; rf != nil {
+		switch rf.Type {
+		case "json_object":
+			gen.ResponseMimeType = "application/json"
+		case "json_schema":
+			gen.ResponseMimeType = "application/json"
+			if rf.JSONSchema != nil {
+				gen.ResponseSchema = geminiSchema(rf.JSONSchema.Schema)
+			}
+		}
+	}
+	out.GenerationConfig = gen
+
+	toolNames := make(map[string]string)
+	var system []geminiPart
+	for i, m := range req.Messages {
+		field := fmt.Sprintf("messages[%d]", i)
+		var (
+			role  string
+			parts []geminiPart
+		)
+		switch m.Role {
+		case "system", "developer":
+			if text := contentText(m.Content); text != "" {
+				system = append(system, geminiPart{Text: text})
+			}
+			continue
+		case "tool":
+			role = "user"
+			name := m.Name
+			if name == "" {
+				name = toolNames[m.ToolCallID]
+			}
+			parts = []geminiPart{{FunctionResponse: &geminiFunctionResponse{
+				Name:     name,
+				Response: geminiToolResponse(m.Content),
+			}}}
+		case "assistant":
+			role = "model"
+			inner, err := toGeminiParts(field, m.Content)
+			if err != nil {
+				return nil, err
+			}
+			parts = inner
+			for _, tc := range m.ToolCalls {
+				toolNames[tc.ID] = tc.Function.Name
+				parts = append(parts, geminiPart{FunctionCall: &geminiFunctionCall{
+					Name: tc.Function.Name,
+					Args: toolInput(tc.Function.Arguments),
+				}})
+			}
+		default:
+			role = "user"
+			inner, err := toGeminiParts(field, m.Content)
+			if err != nil {
+				return nil, err
+			}
+			parts = inner
+		}
+		if len(parts) == 0 {
+			continue
+		}
+		if n := len(out.Contents); n > 0 && out.Contents[n-1].Role == role {
+			out.Contents[n-1].Parts = append(out.Contents[n-1].Parts, parts...)
+			continue
+		}
+		out.Contents = append(out.Contents, geminiContent{Role: role, Parts: parts})
+	}
+	if len(system) > 0 {
+		out.SystemInstruction = &geminiContent{Parts: system}
+	}
+
+	if len(req.Tools) > 0 {
+		decls := make([]geminiFunctionDeclaration, len(req.Tools))
+		for i, t := range req.Tools {
+			decls[i] = geminiFunctionDeclaration{
+				Name:        t.Function.Name,
+				Description: t.Function.Description,
+				Parameters:  geminiSchema(t.Function.Parameters),
+			}
+		}
+		out.Tools = []geminiTool{{FunctionDeclarations: decls}}
+	}
+	out.ToolConfig = toGeminiToolConfig(req.ToolChoice)
+	return out, nil
+}
+
+func toGeminiParts(field string, content any) ([]geminiPart, error) {
+	parts, err := contentParts(content)
+	if err != nil {
+		return nil, &ValidationError{Field: field + ".content", Message: err.Error()}
+	}
+	out := make([]geminiPart, 0, len(parts))
+	for j, p := range parts {
+		partField := fmt.Sprintf("%s.content[%d]", field, j)
+		switch p.Type {
+		case "text":
+			if p.Text != "" {
+				out = append(out, geminiPart{Text: p.Text})
+			}
+		case "image_url", "video_url":
+			u := p.ImageURL
+			fallback := "image/jpeg"
+			if p.Type == "video_url" {
+				u, fallback = p.VideoURL, "video/mp4"
+			}
+			if u == nil {
+				return nil, &ValidationError{Field: partField + "." + p.Type, Message: "cannot be nil"}
+			}
+			out = append(out, geminiMediaPart(u.URL, "", fallback))
+		case "input_audio":
+			if p.InputAudio == nil {
+				return nil, &ValidationError{Field: partField + ".input_audio", Message: "cannot be nil"}
+			}
+			out = append(out, geminiPart{InlineData: &geminiBlob{MimeType: "audio/" + p.InputAudio.Format, Data: p.InputAudio.Data}})
+		case "file":
+			if p.File == nil {
+				return nil, &ValidationError{Field: partField + ".file", Message: "cannot be nil"}
+			}
+			out = append(out, geminiMediaPart(p.File.FileData, p.File.Filename, "application/pdf"))
+		default:
+			return nil, &ValidationError{Field: partField + ".type", Message: fmt.Sprintf("%q is not supported by gemini", p.Type)}
+		}
+	}
+	return out, nil
+}
+
+// geminiMediaPart returns an inlineData part for data URLs and a fileData part otherwise.
+// The MIME type of a file URI is guessed from filename or the URI's extension, falling back to fallback.
+func geminiMediaPart(u, filename, fallback string) geminiPart {
+	if mediaType, data, ok := parseDataURL(u); ok {
+		return geminiPart{InlineData: &geminiBlob{MimeType: mediaType, Data: data}}
+	}
+	mimeType := ""
+	for _, name := range []string{filename, u} {
+		if name == "" {
+			continue
+		}
+		if parsed, err := url.Parse(name); err == nil && parsed.Path != "" {
+			name = parsed.Path
+		}
+		if t := mime.TypeByExtension(path.Ext(name)); t != "" {
+			mimeType, _, _ = strings.Cut(t, ";")
+			break
+		}
+	}
+	if mimeType == "" {
+		mimeType = fallback
+	}
+	return geminiPart{FileData: &geminiFileData{MimeType: mimeType, FileURI: u}}
+}
+
+// geminiToolResponse wraps tool output as the JSON object Gemini expects in functionResponse.response.
+func geminiToolResponse(content any) json.RawMessage {
+	text := contentText(content)
+	var obj map[string]json.RawMessage
+	if json.Unmarshal([]byte(text), &obj) == nil {
+		return json.RawMessage(text)
+	}
+	raw, _ := json.Marshal(map[string]string{"content": text})
+	return raw
+}
+
+// geminiSchema copies a JSON Schema, dropping keywords that Gemini's OpenAPI-subset
+// schema rejects ("$schema", "additionalProperties", "strict") and rewriting nullable
+// types such as ["string", "null"] as "nullable": true.
+func geminiSchema(schema map[string]any) map[string]any {
+	if schema == nil {
+		return nil
+	}
+	out := make(map[string]any, len(schema))
+	for k, v := range schema {
+		switch k {
+		case "$schema", "additionalProperties", "strict":
+			continue
+		case "type":
+			if types, ok := v.([]any); ok {
+				for _, t := range types {
+					if t == "null" {
+						out["nullable"] = true
+					} else {
+						out["type"] = t
+					}
+				}
+				continue
+			}
+		case "enum":
+			if values, ok := v.([]any); ok {
+				v = slices.DeleteFunc(slices.Clone(values), func(e any) bool { return e == nil })
+			}
+		}
+		out[k] = geminiSchemaValue(v)
+	}
+	return out
+}
+
+func geminiSchemaValue(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		return geminiSchema(t)
+	case []any:
+		out := make([]any, len(t))
+		for i, e := range t {
+			out[i] = geminiSchemaValue(e)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// toGeminiToolConfig maps OpenAI-style tool_choice values onto functionCallingConfig modes.
+func toGeminiToolConfig(choice any) *geminiToolConfig {
+	switch v := choice.(type) {
+	case string:
+		switch v {
+		case "auto":
+			return &geminiToolConfig{FunctionCallingConfig: geminiFunctionCallingConfig{Mode: "AUTO"}}
+		case "none":
+			return &geminiToolConfig{FunctionCallingConfig: geminiFunctionCallingConfig{Mode: "NONE"}}
+		case "required", "any":
+			return &geminiToolConfig{FunctionCallingConfig: geminiFunctionCallingConfig{Mode: "ANY"}}
+		}
+	case map[string]any:
+		if fn, ok := v["function"].(map[string]any); ok {
+			if name, _ := fn["name"].(string); name != "" {
+				return &geminiToolConfig{FunctionCallingConfig: geminiFunctionCallingConfig{Mode: "ANY", AllowedFunctionNames: []string{name}}}
+			}
+		}
+	}
+	return nil
+}
+
+func (r *geminiResponse) model(fallback string) string {
+	if r.ModelVersion != "" {
+		return r.ModelVersion
+	}
+	return fallback
+}
+
+func (r *geminiResponse) toLLM(model string) *ChatResponse {
+	out := &ChatResponse{
+		ID:      r.ResponseID,
+		Object:  "chat.completion",
+		Model:   r.model(model),
+		Choices: make([]Choice, len(r.Candidates)),
+		Usage:   r.UsageMetadata.toLLM(),
+	}
+	numTools := 0
+	for i, cand := range r.Candidates {
+		msg := cand.Content.toLLM(r.ResponseID, &numTools)
+		for j := range msg.ToolCalls {
+			msg.ToolCalls[j].Index = nil
+		}
+		out.Choices[i] = Choice{
+			Index:        cand.Index,
+			Message:      msg,
+			FinishReason: geminiFinishReason(cand.FinishReason, len(msg.ToolCalls) > 0),
+		}
+	}
+	return out
+}
+
+// toLLM converts candidate content to a Message. Thought parts go to Reasoning. Gemini
+// function calls usually carry no ID, so one is derived from responseID and a running
+// tool-call counter that also provides ToolCall.Index.
+func (c geminiContent) toLLM(responseID string, numTools *int) *Message {
+	msg := &Message{Role: "assistant"}
+	var text, reasoning strings.Builder
+	for _, p := range c.Parts {
+		switch {
+		case p.FunctionCall != nil:
+			idx := *numTools
+			*numTools++
+			id := p.FunctionCall.ID
+			if id == "" {
+				id = fmt.Sprintf("call_%s_%d", responseID, idx)
+			}
+			args := string(p.FunctionCall.Args)
+			if args == "" {
+				args = "{}"
+			}
+			msg.ToolCalls = append(msg.ToolCalls, ToolCall{
+				Index:    &idx,
+				ID:       id,
+				Type:     "function",
+				Function: FunctionCall{Name: p.FunctionCall.Name, Arguments: args},
+			})
+		case p.Thought:
+			reasoning.WriteString(p.Text)
+		default:
+			text.WriteString(p.Text)
+		}
+	}
+	msg.Content = text.String()
+	msg.Reasoning = reasoning.String()
+	return msg
+}
+
+func (u *geminiUsageMetadata) toLLM() *Usage {
+	if u == nil {
+		return nil
+	}
+	completion := u.CandidatesTokenCount + u.ThoughtsTokenCount
+	total := u.TotalTokenCount
+	if total == 0 {
+		total = u.PromptTokenCount + completion
+	}
+	return &Usage{
+		PromptTokens:     u.PromptTokenCount,
+		CompletionTokens: completion,
+		TotalTokens:      total,
+	}
+}
+
+// geminiFinishReason maps Gemini finish reasons to OpenAI-style finish reasons.
+func geminiFinishReason(reason string, hasToolCalls bool) string {
+	switch reason {
+	case "":
+		return ""
+	case "STOP":
+		if hasToolCalls {
+			return "tool_calls"
+		}
+		return "stop"
+	case "MAX_TOKENS":
+		return "length"
+	case "SAFETY", "RECITATION", "BLOCKLIST", "PROHIBITED_CONTENT", "SPII", "IMAGE_SAFETY":
+		return "content_filter"
+	default:
+		return strings.ToLower(reason)
+	}
+}
@@ -0,0 +1,231 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTestGeminiClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	client, err := NewClient(ProviderGemini, WithAPIKey("g-test"), WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return client
+}
+
+func TestToGeminiRequest(t *testing.T) {
+	req := &ChatRequest{
+		Model: "gemini-2.5-flash",
+		Messages: []Message{
+			{Role: "system", Content: "be brief"},
+			{Role: "user", Content: []ContentPart{
+				{Type: "text", Text: "describe"},
+				{Type: "image_url", ImageURL: &ImageURL{URL: "data:image/png;base64,AAAA"}},
+				{Type: "video_url", VideoURL: &ImageURL{URL: "https://example.com/clip.mp4"}},
+				{Type: "input_audio", InputAudio: &InputAudio{Data: "BBBB", Format: "wav"}},
+				{Type: "file", File: &FileData{Filename: "doc.pdf", FileData: "gs://bucket/doc"}},
+			}},
+			{Role: "assistant", ToolCalls: []ToolCall{{ID: "c1", Function: FunctionCall{Name: "lookup", Arguments: `{"q":"x"}`}}}},
+			{Role: "tool", ToolCallID: "c1", Content: "plain result"},
+		},
+		Tools: []Tool{{Type: "function", Function: FunctionDef{Name: "lookup", Parameters: map[string]any{
+			"type":                 "object",
+			"additionalProperties": false,
+			"properties":           map[string]any{"q": map[string]any{"type": "string"}},
+		}}}},
+		ToolChoice: "required",
+		ResponseFormat: &ResponseFormat{Type: "json_schema", JSONSchema: &JSONSchemaDef{Name: "out", Schema: map[string]any{
+			"type": "object", "additionalProperties": false,
+		}}},
+	}
+	got, err := toGeminiRequest(req)
+	if err != nil {
+		t.Fatalf("toGeminiRequest: %v", err)
+	}
+	if got.SystemInstruction == nil || got.SystemInstruction.Parts[0].Text != "be brief" {
+		t.Errorf("SystemInstruction = %+v", got.SystemInstruction)
+	}
+	if len(got.Contents) != 3 {
+		t.Fatalf("len(Contents) = %d, want 3", len(got.Contents))
+	}
+	parts := got.Contents[0].Parts
+	if parts[1].InlineData == nil || parts[1].InlineData.MimeType != "image/png" {
+		t.Errorf("image part = %+v", parts[1])
+	}
+	if parts[2].FileData == nil || parts[2].FileData.MimeType != "video/mp4" {
+		t.Errorf("video part = %+v", parts[2])
+	}
+	if parts[3].InlineData == nil || parts[3].InlineData.MimeType != "audio/wav" {
+		t.Errorf("audio part = %+v", parts[3])
+	}
+	if parts[4].FileData == nil || parts[4].FileData.MimeType != "application/pdf" || parts[4].FileData.FileURI != "gs://bucket/doc" {
+		t.Errorf("file part = %+v", parts[4].FileData)
+	}
+	if got.Contents[1].Role != "model" || got.Contents[1].Parts[0].FunctionCall.Name != "lookup" {
+		t.Errorf("model turn = %+v", got.Contents[1])
+	}
+	fr := got.Contents[2].Parts[0].FunctionResponse
+	if fr == nil || fr.Name != "lookup" || string(fr.Response) != `{"content":"plain result"}` {
+		t.Errorf("function response = %+v", fr)
+	}
+	params := got.Tools[0].FunctionDeclarations[0].Parameters
+	if _, ok := params["additionalProperties"]; ok {
+		t.Error("additionalProperties should be stripped from parameters")
+	}
+	if got.ToolConfig == nil || got.ToolConfig.FunctionCallingConfig.Mode != "ANY" {
+		t.Errorf("ToolConfig = %+v", got.ToolConfig)
+	}
+	gen := got.GenerationConfig
+	if gen.ResponseMimeType != "application/json" || gen.ResponseSchema["type"] != "object" {
+		t.Errorf("GenerationConfig = %+v", gen)
+	}
+}
+
+func TestGeminiChat_Create(t *testing.T) {
+	client := newTestGeminiClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models/gemini-2.5-flash:generateContent" {
+			t.Errorf("path = %q", r.URL.Path)
+		}
+		if r.Header.Get("x-goog-api-key") != "g-test" {
+			t.Errorf("x-goog-api-key = %q", r.Header.Get("x-goog-api-key"))
+		}
+		io.WriteString(w, `{"candidates":[{"index":0,"content":{"role":"model","parts":[{"text":"thinking...","thought":true},{"text":"Answer"},{"functionCall":{"name":"lookup","args":{"q":"x"}}}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":4,"candidatesTokenCount":2,"thoughtsTokenCount":1,"totalTokenCount":7},"responseId":"r1"}`)
+	})
+	resp, err := client.Chat.Create(context.Background(), &ChatRequest{Model: "models/gemini-2.5-flash", Messages: []Message{{Role: "user", Content: "hi"}}})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	msg := resp.Choices[0].Message
+	if msg.Content != "Answer" || msg.Reasoning != "thinking..." {
+		t.Errorf("Message = %+v", msg)
+	}
+	if len(msg.ToolCalls) != 1 || msg.ToolCalls[0].ID == "" || msg.ToolCalls[0].Function.Arguments != `{"q":"x"}` {
+		t.Errorf("ToolCalls = %+v", msg.ToolCalls)
+	}
+	if resp.Choices[0].FinishReason != "tool_calls" {
+		t.Errorf("FinishReason = %q", resp.Choices[0].FinishReason)
+	}
+	if resp.Usage == nil || resp.Usage.CompletionTokens != 3 || resp.Usage.TotalTokens != 7 {
+		t.Errorf("Usage = %+v", resp.Usage)
+	}
+}
+
+func TestGeminiChat_CreateStream(t *testing.T) {
+	client := newTestGeminiClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models/gemini-2.5-flash:streamGenerateContent" || r.URL.Query().Get("alt") != "sse" {
+			t.Errorf("url = %q", r.URL.String())
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "data: {\"candidates\":[{\"content\":{\"role\":\"model\",\"parts\":[{\"text\":\"Hel\"}]}}]}\r\n\r\n")
+		io.WriteString(w, "data: {\"candidates\":[{\"content\":{\"role\":\"model\",\"parts\":[{\"text\":\"lo\"}]},\"finishReason\":\"STOP\"}],\"usageMetadata\":{\"promptTokenCount\":1,\"candidatesTokenCount\":2,\"totalTokenCount\":3}}\r\n\r\n")
+	})
+	stream, err := client.Chat.CreateStream(context.Background(), &ChatRequest{Model: "gemini-2.5-flash", Messages: []Message{{Role: "user", Content: "hi"}}})
+	if err != nil {
+		t.Fatalf("CreateStream: %v", err)
+	}
+	defer stream.Close()
+	var text strings.Builder
+	var last *StreamChunk
+	for {
+		chunk, err := stream.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		text.WriteString(chunk.Choices[0].Delta.Content.(string))
+		last = chunk
+	}
+	if text.String() != "Hello" {
+		t.Errorf("text = %q", text.String())
+	}
+	if last == nil || last.Choices[0].FinishReason != "stop" || last.Usage == nil || last.Usage.TotalTokens != 3 {
+		t.Errorf("last chunk = %+v", last)
+	}
+}
+
+func TestGeminiChat_CreateStreamToolCallsAndErrors(t *testing.T) {
+	client := newTestGeminiClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "data: {\"candidates\":[{\"content\":{\"role\":\"model\",\"parts\":[{\"functionCall\":{\"name\":\"get_weather\",\"args\":{\"city\":\"Paris\"}}}]}}]}\r\n\r\n")
+		io.WriteString(w, "data: {\"candidates\":[{\"content\":{\"role\":\"model\",\"parts\":[{\"text\":\"\"}]},\"finishReason\":\"STOP\"}]}\r\n\r\n")
+		io.WriteString(w, "data: {\"error\":{\"code\":429,\"status\":\"RESOURCE_EXHAUSTED\",\"message\":\"quota\"}}\r\n\r\n")
+	})
+	stream, err := client.Chat.CreateStream(context.Background(), &ChatRequest{Model: "gemini-2.5-flash", Messages: []Message{{Role: "user", Content: "hi"}}})
+	if err != nil {
+		t.Fatalf("CreateStream: %v", err)
+	}
+	defer stream.Close()
+	if _, err := stream.Next(); err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	last, err := stream.Next()
+	if err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	if got := last.Choices[0].FinishReason; got != "tool_calls" {
+		t.Errorf("finish reason = %q, want tool_calls from an earlier chunk", got)
+	}
+	_, err = stream.Next()
+	var ae *APIError
+	if !errors.As(err, &ae) || ae.StatusCode != 0 || !errors.Is(err, ErrRateLimited) {
+		t.Errorf("in-stream err = %#v", err)
+	}
+}
+
+func TestGeminiEmbedding_Create(t *testing.T) {
+	client := newTestGeminiClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/models/text-embedding-004:embedContent":
+			io.WriteString(w, `{"embedding":{"values":[0.1,0.2]}}`)
+		case "/models/text-embedding-004:batchEmbedContents":
+			var body geminiBatchEmbedRequest
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if len(body.Requests) != 2 || body.Requests[0].Model != "models/text-embedding-004" {
+				t.Errorf("batch request = %+v", body)
+			}
+			io.WriteString(w, `{"embeddings":[{"values":[0.1]},{"values":[0.2]}]}`)
+		default:
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+	})
+	single, err := client.Embeddings.Create(context.Background(), &EmbeddingRequest{Model: "text-embedding-004", Input: "hi"})
+	if err != nil {
+		t.Fatalf("Create single: %v", err)
+	}
+	if len(single.Data) != 1 || len(single.Data[0].Embedding) != 2 {
+		t.Errorf("single = %+v", single.Data)
+	}
+	batch, err := client.Embeddings.Create(context.Background(), &EmbeddingRequest{Model: "text-embedding-004", Input: []string{"a", "b"}})
+	if err != nil {
+		t.Fatalf("Create batch: %v", err)
+	}
+	if len(batch.Data) != 2 || batch.Data[1].Index != 1 || batch.Data[1].Embedding[0] != 0.2 {
+		t.Errorf("batch = %+v", batch.Data)
+	}
+}
+
+func TestGeminiSchema_Nullable(t *testing.T) {
+	got := geminiSchema(map[string]any{
+		"type":                 "object",
+		"additionalProperties": false,
+		"properties": map[string]any{
+			"unit": map[string]any{"type": []any{"string", "null"}, "enum": []any{"c", "f", nil}},
+		},
+	})
+	raw, _ := json.Marshal(got)
+	want := `{"properties":{"unit":{"enum":["c","f"],"nullable":true,"type":"string"}},"type":"object"}`
+	if string(raw) != want {
+		t.Errorf("schema = %s, want %s", raw, want)
+	}
+}
@@ -5,12 +5,12 @@ go 1.23.2
 require (
 	github.com/MetaDiv-AI/logger v1.0.0
 	github.com/MetaDiv-AI/openrouter v1.2.3
+	go.uber.org/zap v1.27.1
 )
 
 require (
 	github.com/MetaDiv-AI/http_caller v1.0.0 // indirect
 	go.uber.org/multierr v1.11.0 // indirect
-	go.uber.org/zap v1.27.1 // indirect
 )
 
 replace github.com/MetaDiv-AI/openrouter => ../openrouter
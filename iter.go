@@ -0,0 +1,57 @@
+package llm
+
+import (
+	"errors"
+	"io"
+	"iter"
+)
+
+// Chunks returns an iterator over the chunks of r for use with range:
+//
+//	for chunk, err := range llm.Chunks(stream) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+//
+// Iteration ends at io.EOF, which is not yielded; a chunk returned together with io.EOF
+// is yielded first. Any other error is yielded once with a nil chunk and ends iteration.
+// r is closed when iteration ends, including when the loop breaks early.
+func Chunks(r StreamReader) iter.Seq2[*StreamChunk, error] {
+	return func(yield func(*StreamChunk, error) bool) {
+		defer r.Close()
+		for {
+			chunk, err := r.Next()
+			if chunk != nil && !yield(chunk, nil) {
+				return
+			}
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+		}
+	}
+}
+
+// TextDeltas returns an iterator over the non-empty text content deltas of the first choice
+// of r. Errors and closing behave as in Chunks.
+func TextDeltas(r StreamReader) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		for chunk, err := range Chunks(r) {
+			if err != nil {
+				yield("", err)
+				return
+			}
+			if len(chunk.Choices) == 0 || chunk.Choices[0].Delta == nil {
+				continue
+			}
+			if text := contentText(chunk.Choices[0].Delta.Content); text != "" && !yield(text, nil) {
+				return
+			}
+		}
+	}
+}
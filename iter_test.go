@@ -0,0 +1,70 @@
+package llm
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+// eofWithChunkStream returns its last chunk together with io.EOF, as orStreamReader can.
+type eofWithChunkStream struct {
+	MockStreamReader
+}
+
+func (s *eofWithChunkStream) Next() (*StreamChunk, error) {
+	chunk, err := s.MockStreamReader.Next()
+	if err == nil && s.pos == len(s.Chunks) {
+		return chunk, io.EOF
+	}
+	return chunk, err
+}
+
+func TestChunks(t *testing.T) {
+	stream := &eofWithChunkStream{MockStreamReader{Chunks: []*StreamChunk{textChunk("a"), textChunk("b")}}}
+	var got []string
+	for chunk, err := range Chunks(stream) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, chunk.Choices[0].Delta.Content.(string))
+	}
+	if len(got) != 2 || got[1] != "b" {
+		t.Errorf("got = %v, want the chunk delivered with io.EOF", got)
+	}
+	if !stream.Closed {
+		t.Error("stream should be closed")
+	}
+}
+
+func TestChunks_BreakClosesStream(t *testing.T) {
+	stream := &MockStreamReader{Chunks: []*StreamChunk{textChunk("a"), textChunk("b")}}
+	for range Chunks(stream) {
+		break
+	}
+	if !stream.Closed {
+		t.Error("stream should be closed after break")
+	}
+}
+
+func TestTextDeltas(t *testing.T) {
+	boom := errors.New("boom")
+	stream := &MockStreamReader{
+		Chunks: []*StreamChunk{textChunk("Hel"), {Usage: &Usage{}}, textChunk(""), textChunk("lo")},
+		Err:    boom,
+	}
+	var text string
+	var gotErr error
+	for delta, err := range TextDeltas(stream) {
+		if err != nil {
+			gotErr = err
+			break
+		}
+		text += delta
+	}
+	if text != "Hello" || !errors.Is(gotErr, boom) {
+		t.Errorf("text = %q, err = %v", text, gotErr)
+	}
+	if !stream.Closed {
+		t.Error("stream should be closed")
+	}
+}
@@ -0,0 +1,289 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/MetaDiv-AI/llm"
+)
+
+// DefaultClientInfo identifies Client to servers when ClientOptions.Info is empty.
+var DefaultClientInfo = Implementation{Name: "github.com/MetaDiv-AI/llm", Version: "1.0.0"}
+
+// ClientOptions configures Connect.
+type ClientOptions struct {
+	// Info identifies the client to the server (default DefaultClientInfo).
+	Info Implementation
+}
+
+// Client is a connection to an MCP server. It is safe for concurrent use.
+type Client struct {
+	t      Transport
+	nextID atomic.Int64
+
+	mu      sync.Mutex
+	pending map[string]chan *RPCMessage
+	err     error // set when the connection is lost
+	done    chan struct{}
+
+	// ServerInfo, ServerCapabilities and Instructions are reported by the server during
+	// initialization.
+	ServerInfo         Implementation
+	ServerCapabilities map[string]any
+	Instructions       string
+}
+
+// Connect performs the MCP initialization handshake over t and returns a ready Client.
+// The Client owns t and closes it on Close or when initialization fails.
+func Connect(ctx context.Context, t Transport, opts ClientOptions) (*Client, error) {
+	if opts.Info.Name == "" {
+		opts.Info = DefaultClientInfo
+	}
+	c := &Client{t: t, pending: make(map[string]chan *RPCMessage), done: make(chan struct{})}
+	go c.readLoop()
+
+	var res initializeResult
+	params := initializeParams{ProtocolVersion: ProtocolVersion, Capabilities: map[string]any{}, ClientInfo: opts.Info}
+	if err := c.call(ctx, "initialize", params, &res); err != nil {
+		c.Close()
+		return nil, err
+	}
+	c.ServerInfo = res.ServerInfo
+	c.ServerCapabilities = res.Capabilities
+	c.Instructions = res.Instructions
+	if err := c.notify(ctx, "notifications/initialized", nil); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Close closes the connection. Pending calls fail with ErrTransportClosed.
+func (c *Client) Close() error {
+	err := c.t.Close()
+	c.fail(ErrTransportClosed)
+	return err
+}
+
+// ListTools returns every tool the server offers, following pagination.
+func (c *Client) ListTools(ctx context.Context) ([]Tool, error) {
+	var tools []Tool
+	cursor := ""
+	for {
+		var res listToolsResult
+		if err := c.call(ctx, "tools/list", listToolsParams{Cursor: cursor}, &res); err != nil {
+			return nil, err
+		}
+		tools = append(tools, res.Tools...)
+		if res.NextCursor == "" {
+			return tools, nil
+		}
+		cursor = res.NextCursor
+	}
+}
+
+// ChatTools returns the server's tools as llm.Tool values for ChatRequest.Tools.
+func (c *Client) ChatTools(ctx context.Context) ([]llm.Tool, error) {
+	tools, err := c.ListTools(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]llm.Tool, len(tools))
+	for i, t := range tools {
+		out[i] = llm.Tool{Type: "function", Function: t.FunctionDef()}
+	}
+	return out, nil
+}
+
+// CallTool invokes the named tool. arguments is marshaled to JSON unless it already is a
+// json.RawMessage; nil sends no arguments. A result with IsError set is returned without error.
+func (c *Client) CallTool(ctx context.Context, name string, arguments any) (*CallToolResult, error) {
+	params := callToolParams{Name: name}
+	switch a := arguments.(type) {
+	case nil:
+	case json.RawMessage:
+		params.Arguments = a
+	default:
+		raw, err := json.Marshal(a)
+		if err != nil {
+			return nil, err
+		}
+		params.Arguments = raw
+	}
+	var res CallToolResult
+	if err := c.call(ctx, "tools/call", params, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// Call dispatches a model's tool call to the server and returns the tool message answering it,
+// mirroring llm.ToolRegistry.Call: on failure the message content reports the error to the
+// model and the error is also returned. Tool-level failures return a *ToolError.
+func (c *Client) Call(ctx context.Context, call llm.ToolCall) (llm.Message, error) {
+	msg := llm.Message{Role: "tool", ToolCallID: call.ID, Name: call.Function.Name}
+	out, err := c.callFunc(call.Function.Name)(ctx, call.Function.Arguments)
+	if err != nil {
+		msg.Content = "error: " + err.Error()
+		return msg, err
+	}
+	msg.Content = out
+	return msg, nil
+}
+
+// Register adds every server tool to r, so an llm.Agent using r dispatches them to this client.
+func (c *Client) Register(ctx context.Context, r *llm.ToolRegistry) error {
+	tools, err := c.ListTools(ctx)
+	if err != nil {
+		return err
+	}
+	for _, t := range tools {
+		if err := r.RegisterFunc(t.FunctionDef(), c.callFunc(t.Name)); err != nil {
+			return fmt.Errorf("mcp: register tool %q: %w", t.Name, err)
+		}
+	}
+	return nil
+}
+
+// callFunc returns a function calling the named tool with raw JSON arguments and flattening
+// the result to text.
+func (c *Client) callFunc(name string) func(context.Context, string) (string, error) {
+	return func(ctx context.Context, arguments string) (string, error) {
+		var args any
+		if strings.TrimSpace(arguments) != "" {
+			if !json.Valid([]byte(arguments)) {
+				return "", &llm.ValidationError{Field: "arguments", Message: fmt.Sprintf("invalid JSON for tool %s", name)}
+			}
+			args = json.RawMessage(arguments)
+		}
+		res, err := c.CallTool(ctx, name, args)
+		if err != nil {
+			return "", err
+		}
+		if res.IsError {
+			return "", &ToolError{Name: name, Message: res.Text()}
+		}
+		return res.Text(), nil
+	}
+}
+
+// call sends a request and decodes its result into result.
+func (c *Client) call(ctx context.Context, method string, params, result any) error {
+	id := strconv.FormatInt(c.nextID.Add(1), 10)
+	ch := make(chan *RPCMessage, 1)
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return c.err
+	}
+	c.pending[id] = ch
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	msg := &RPCMessage{JSONRPC: "2.0", ID: json.RawMessage(id), Method: method}
+	if params != nil {
+		raw, err := json.Marshal(params)
+		if err != nil {
+			return err
+		}
+		msg.Params = raw
+	}
+	if err := c.t.Send(ctx, msg); err != nil {
+		return err
+	}
+	select {
+	case resp := <-ch:
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if result == nil || len(resp.Result) == 0 {
+			return nil
+		}
+		return json.Unmarshal(resp.Result, result)
+	case <-ctx.Done():
+		c.notify(context.Background(), "notifications/cancelled", map[string]any{"requestId": json.RawMessage(id), "reason": ctx.Err().Error()})
+		return ctx.Err()
+	case <-c.done:
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return c.err
+	}
+}
+
+func (c *Client) notify(ctx context.Context, method string, params any) error {
+	msg := &RPCMessage{JSONRPC: "2.0", Method: method}
+	if params != nil {
+		raw, err := json.Marshal(params)
+		if err != nil {
+			return err
+		}
+		msg.Params = raw
+	}
+	return c.t.Send(ctx, msg)
+}
+
+// readLoop routes responses to their callers and answers server requests until the
+// transport fails.
+func (c *Client) readLoop() {
+	for {
+		msg, err := c.t.Receive()
+		if errors.Is(err, errInvalidMessage) {
+			continue
+		}
+		if err != nil {
+			c.fail(err)
+			return
+		}
+		switch {
+		case msg.isRequest():
+			go c.answer(msg)
+		case msg.isNotification():
+			// Notifications such as progress and list_changed are not used.
+		default:
+			// Each ID is answered once: duplicate or late responses are dropped rather
+			// than blocking the loop on a channel nobody reads.
+			c.mu.Lock()
+			ch := c.pending[string(msg.ID)]
+			delete(c.pending, string(msg.ID))
+			c.mu.Unlock()
+			if ch != nil {
+				ch <- msg
+			}
+		}
+	}
+}
+
+// answer replies to a server-initiated request. Only ping is supported.
+func (c *Client) answer(req *RPCMessage) {
+	resp := &RPCMessage{JSONRPC: "2.0", ID: req.ID}
+	if req.Method == "ping" {
+		resp.Result = json.RawMessage("{}")
+	} else {
+		resp.Error = &Error{Code: CodeMethodNotFound, Message: "method not found: " + req.Method}
+	}
+	c.t.Send(context.Background(), resp)
+}
+
+// fail records the first connection error and wakes pending calls.
+func (c *Client) fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return
+	}
+	if !errors.Is(err, ErrTransportClosed) {
+		err = fmt.Errorf("mcp: connection lost: %w", err)
+	}
+	c.err = err
+	close(c.done)
+}
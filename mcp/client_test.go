@@ -0,0 +1,352 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MetaDiv-AI/llm"
+)
+
+// fakeServer answers MCP requests in-process. It offers "echo", which returns its text
+// argument, and "fail", which reports a tool error, split over two tools/list pages.
+type fakeServer struct {
+	mu           sync.Mutex
+	initialized  bool
+	lastArgs     json.RawMessage
+	pingAnswered bool
+}
+
+func (s *fakeServer) handle(msg *RPCMessage) *RPCMessage {
+	if msg.isNotification() {
+		if msg.Method == "notifications/initialized" {
+			s.mu.Lock()
+			s.initialized = true
+			s.mu.Unlock()
+		}
+		return nil
+	}
+	if !msg.isRequest() {
+		s.mu.Lock()
+		s.pingAnswered = string(msg.ID) == `"srv-ping"` && msg.Error == nil
+		s.mu.Unlock()
+		return nil
+	}
+	resp := &RPCMessage{JSONRPC: "2.0", ID: msg.ID}
+	var result any
+	switch msg.Method {
+	case "initialize":
+		var p initializeParams
+		json.Unmarshal(msg.Params, &p)
+		if p.ProtocolVersion != ProtocolVersion || p.ClientInfo.Name == "" {
+			resp.Error = &Error{Code: CodeInvalidParams, Message: "bad initialize"}
+			return resp
+		}
+		result = initializeResult{ProtocolVersion: ProtocolVersion, ServerInfo: Implementation{Name: "fake", Version: "0.1"}, Instructions: "be nice"}
+	case "tools/list":
+		var p listToolsParams
+		json.Unmarshal(msg.Params, &p)
+		if p.Cursor == "" {
+			result = listToolsResult{Tools: []Tool{{
+				Name:        "echo",
+				Description: "Echo text",
+				InputSchema: map[string]any{"type": "object", "properties": map[string]any{"text": map[string]any{"type": "string"}}},
+			}}, NextCursor: "page2"}
+		} else {
+			result = listToolsResult{Tools: []Tool{{Name: "fail"}}}
+		}
+	case "tools/call":
+		var p callToolParams
+		json.Unmarshal(msg.Params, &p)
+		s.mu.Lock()
+		s.lastArgs = p.Arguments
+		s.mu.Unlock()
+		switch p.Name {
+		case "echo":
+			var args struct {
+				Text string `json:"text"`
+			}
+			json.Unmarshal(p.Arguments, &args)
+			result = CallToolResult{Content: []Content{{Type: "text", Text: args.Text}, {Type: "text", Text: "done"}}}
+		case "fail":
+			result = CallToolResult{Content: []Content{{Type: "text", Text: "boom"}}, IsError: true}
+		default:
+			resp.Error = &Error{Code: CodeInvalidParams, Message: "unknown tool " + p.Name}
+			return resp
+		}
+	default:
+		resp.Error = &Error{Code: CodeMethodNotFound, Message: "method not found"}
+		return resp
+	}
+	resp.Result, _ = json.Marshal(result)
+	return resp
+}
+
+// serveStream runs s over newline-delimited JSON, pinging the client once it is initialized.
+func (s *fakeServer) serveStream(r io.Reader, w io.WriteCloser) {
+	defer w.Close()
+	var mu sync.Mutex
+	write := func(msg *RPCMessage) {
+		raw, _ := json.Marshal(msg)
+		mu.Lock()
+		defer mu.Unlock()
+		w.Write(append(raw, '\n'))
+	}
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		var msg RPCMessage
+		if err := json.Unmarshal(sc.Bytes(), &msg); err != nil {
+			continue
+		}
+		if resp := s.handle(&msg); resp != nil {
+			write(resp)
+		}
+		if msg.Method == "notifications/initialized" {
+			write(&RPCMessage{JSONRPC: "2.0", ID: json.RawMessage(`"srv-ping"`), Method: "ping"})
+		}
+	}
+}
+
+func connectStream(t *testing.T, s *fakeServer) *Client {
+	t.Helper()
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+	go s.serveStream(serverR, serverW)
+	c, err := Connect(context.Background(), NewStreamTransport(clientR, clientW), ClientOptions{})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestClient_Stdio(t *testing.T) {
+	s := &fakeServer{}
+	c := connectStream(t, s)
+	ctx := context.Background()
+	if c.ServerInfo.Name != "fake" || c.Instructions != "be nice" {
+		t.Errorf("server info = %+v, instructions = %q", c.ServerInfo, c.Instructions)
+	}
+
+	tools, err := c.ChatTools(ctx)
+	if err != nil {
+		t.Fatalf("ChatTools: %v", err)
+	}
+	if len(tools) != 2 || tools[0].Type != "function" || tools[0].Function.Name != "echo" || tools[1].Function.Name != "fail" {
+		t.Fatalf("tools = %+v", tools)
+	}
+	if tools[1].Function.Parameters["type"] != "object" {
+		t.Errorf("missing schema should default to an object: %v", tools[1].Function.Parameters)
+	}
+
+	msg, err := c.Call(ctx, llm.ToolCall{ID: "c1", Function: llm.FunctionCall{Name: "echo", Arguments: `{"text":"hi"}`}})
+	if err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	if msg.Role != "tool" || msg.ToolCallID != "c1" || msg.Content != "hi\ndone" {
+		t.Errorf("msg = %+v", msg)
+	}
+
+	msg, err = c.Call(ctx, llm.ToolCall{ID: "c2", Function: llm.FunctionCall{Name: "fail"}})
+	var toolErr *ToolError
+	if !errors.As(err, &toolErr) || toolErr.Message != "boom" || !strings.Contains(msg.Content.(string), "boom") {
+		t.Errorf("fail = %+v, %v", msg, err)
+	}
+	s.mu.Lock()
+	if s.lastArgs != nil {
+		t.Errorf("empty arguments should be omitted, got %s", s.lastArgs)
+	}
+	if !s.initialized || !s.pingAnswered {
+		t.Errorf("initialized = %v, ping answered = %v", s.initialized, s.pingAnswered)
+	}
+	s.mu.Unlock()
+
+	var rpcErr *Error
+	if _, err := c.CallTool(ctx, "missing", nil); !errors.As(err, &rpcErr) || rpcErr.Code != CodeInvalidParams {
+		t.Errorf("missing tool err = %v", err)
+	}
+}
+
+func TestClient_Register(t *testing.T) {
+	c := connectStream(t, &fakeServer{})
+	r := llm.NewToolRegistry()
+	if err := c.Register(context.Background(), r); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if !r.Has("echo") || !r.Has("fail") {
+		t.Fatalf("tools = %+v", r.Tools())
+	}
+	msg, err := r.Call(context.Background(), llm.ToolCall{ID: "c1", Function: llm.FunctionCall{Name: "echo", Arguments: `{"text":"via registry"}`}})
+	if err != nil || msg.Content != "via registry\ndone" {
+		t.Errorf("msg = %+v, err = %v", msg, err)
+	}
+	if _, err := r.Call(context.Background(), llm.ToolCall{Function: llm.FunctionCall{Name: "echo", Arguments: `{bad`}}); !errors.Is(err, llm.ErrInvalidRequest) {
+		t.Errorf("invalid arguments err = %v", err)
+	}
+}
+
+func TestClient_ConnectionLost(t *testing.T) {
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+	s := &fakeServer{}
+	go func() {
+		// Serve the handshake, then hang up on the next request.
+		sc := bufio.NewScanner(serverR)
+		for sc.Scan() {
+			var msg RPCMessage
+			json.Unmarshal(sc.Bytes(), &msg)
+			if msg.Method == "tools/list" {
+				serverW.Close()
+				return
+			}
+			if resp := s.handle(&msg); resp != nil {
+				raw, _ := json.Marshal(resp)
+				serverW.Write(append(raw, '\n'))
+			}
+		}
+	}()
+	c, err := Connect(context.Background(), NewStreamTransport(clientR, clientW), ClientOptions{})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer c.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := c.ListTools(ctx); err == nil || !errors.Is(err, io.EOF) {
+		t.Errorf("err = %v, want connection lost", err)
+	}
+}
+
+// chanTransport receives the messages sent on in and discards sent messages.
+type chanTransport struct {
+	in chan *RPCMessage
+}
+
+func (t chanTransport) Send(context.Context, *RPCMessage) error { return nil }
+func (t chanTransport) Close() error                            { return nil }
+
+func (t chanTransport) Receive() (*RPCMessage, error) {
+	msg, ok := <-t.in
+	if !ok {
+		return nil, io.EOF
+	}
+	return msg, nil
+}
+
+func TestClient_DuplicateResponses(t *testing.T) {
+	tr := chanTransport{in: make(chan *RPCMessage)}
+	first, second := make(chan *RPCMessage, 1), make(chan *RPCMessage, 1)
+	c := &Client{t: tr, pending: map[string]chan *RPCMessage{"1": first, "2": second}, done: make(chan struct{})}
+	go c.readLoop()
+
+	go func() {
+		for _, id := range []string{"1", "1", "1", "2"} {
+			tr.in <- &RPCMessage{JSONRPC: "2.0", ID: json.RawMessage(id), Result: json.RawMessage("{}")}
+		}
+	}()
+	select {
+	case msg := <-second:
+		if string(msg.ID) != "2" {
+			t.Errorf("second = %+v", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("read loop blocked on a duplicate response")
+	}
+	close(tr.in)
+}
+
+// handlerTransport serves HTTP requests with an in-process handler.
+type handlerTransport struct {
+	h http.Handler
+}
+
+func (t handlerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rec := httptest.NewRecorder()
+	t.h.ServeHTTP(rec, req)
+	return rec.Result(), nil
+}
+
+// httpHandler serves s over the streamable HTTP transport, answering tools/call with SSE.
+func (s *fakeServer) httpHandler(deleted *string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			*deleted = r.Header.Get("Mcp-Session-Id")
+			return
+		}
+		var msg RPCMessage
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if msg.Method == "initialize" {
+			w.Header().Set("Mcp-Session-Id", "sess-1")
+		} else if r.Header.Get("Mcp-Session-Id") != "sess-1" {
+			http.Error(w, "missing session", http.StatusBadRequest)
+			return
+		}
+		resp := s.handle(&msg)
+		if resp == nil {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		raw, _ := json.Marshal(resp)
+		if msg.Method == "tools/call" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", raw)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(raw)
+	})
+}
+
+func TestClient_HTTP(t *testing.T) {
+	s := &fakeServer{}
+	var deleted string
+	transport := NewHTTPTransport("http://mcp.test/mcp", &http.Client{Transport: handlerTransport{s.httpHandler(&deleted)}})
+	ctx := context.Background()
+	c, err := Connect(ctx, transport, ClientOptions{Info: Implementation{Name: "test", Version: "1"}})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	tools, err := c.ListTools(ctx)
+	if err != nil || len(tools) != 2 {
+		t.Fatalf("ListTools = %+v, %v", tools, err)
+	}
+	res, err := c.CallTool(ctx, "echo", map[string]string{"text": "over sse"})
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if res.IsError || res.Text() != "over sse\ndone" {
+		t.Errorf("result = %+v", res)
+	}
+	if err := c.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+	if deleted != "sess-1" {
+		t.Errorf("session not ended, DELETE got %q", deleted)
+	}
+	if _, err := c.ListTools(ctx); !errors.Is(err, ErrTransportClosed) {
+		t.Errorf("after Close err = %v", err)
+	}
+}
+
+func TestCallToolResult_Text(t *testing.T) {
+	r := &CallToolResult{Content: []Content{{Type: "text", Text: "a"}, {Type: "image", Data: "AAA", MimeType: "image/png"}}}
+	if got := r.Text(); got != `a`+"\n"+`{"type":"image","data":"AAA","mimeType":"image/png"}` {
+		t.Errorf("Text = %s", got)
+	}
+	r = &CallToolResult{StructuredContent: map[string]int{"n": 1}}
+	if got := r.Text(); got != `{"n":1}` {
+		t.Errorf("Text = %s", got)
+	}
+}
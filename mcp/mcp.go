@@ -0,0 +1,148 @@
+// Package mcp connects llm to the Model Context Protocol.
+//
+// Client talks to MCP servers over stdio (NewCommandTransport, NewStreamTransport) or
+// streamable HTTP (NewHTTPTransport), lists their tools as llm.Tool values and dispatches
+// llm.ToolCalls back to the server, so MCP tools plug directly into ChatRequest.Tools and
+// llm.Agent.
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/MetaDiv-AI/llm"
+)
+
+// ProtocolVersion is the MCP protocol revision spoken by this package.
+const ProtocolVersion = "2025-03-26"
+
+// JSON-RPC error codes.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// RPCMessage is a JSON-RPC 2.0 request, notification or response.
+type RPCMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+func (m *RPCMessage) isRequest() bool      { return m.Method != "" && len(m.ID) > 0 }
+func (m *RPCMessage) isNotification() bool { return m.Method != "" && len(m.ID) == 0 }
+
+// Error is a JSON-RPC error returned by the peer.
+type Error struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("mcp: %s (code %d)", e.Message, e.Code)
+}
+
+// ToolError is returned when a tool call completes with isError set. Message is the text
+// content the server reported.
+type ToolError struct {
+	Name    string
+	Message string
+}
+
+func (e *ToolError) Error() string {
+	return fmt.Sprintf("mcp: tool %s failed: %s", e.Name, e.Message)
+}
+
+// Implementation identifies an MCP client or server.
+type Implementation struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Tool is a tool advertised by an MCP server.
+type Tool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	InputSchema map[string]any `json:"inputSchema"`
+}
+
+// FunctionDef converts t for use in llm.ChatRequest.Tools.
+func (t Tool) FunctionDef() llm.FunctionDef {
+	schema := t.InputSchema
+	if schema == nil {
+		schema = map[string]any{"type": "object", "properties": map[string]any{}}
+	}
+	return llm.FunctionDef{Name: t.Name, Description: t.Description, Parameters: schema}
+}
+
+// Content is one item of a tool result: text, image, audio or an embedded resource.
+type Content struct {
+	Type     string          `json:"type"`
+	Text     string          `json:"text,omitempty"`
+	Data     string          `json:"data,omitempty"`
+	MimeType string          `json:"mimeType,omitempty"`
+	Resource json.RawMessage `json:"resource,omitempty"`
+}
+
+// CallToolResult is the result of tools/call.
+type CallToolResult struct {
+	Content           []Content `json:"content"`
+	StructuredContent any       `json:"structuredContent,omitempty"`
+	IsError           bool      `json:"isError,omitempty"`
+	// Meta carries result metadata (the "_meta" field).
+	Meta map[string]any `json:"_meta,omitempty"`
+}
+
+// Text flattens the result into a tool message: text items are joined by newlines and other
+// items are JSON-encoded. Without content, StructuredContent is JSON-encoded.
+func (r *CallToolResult) Text() string {
+	var parts []string
+	for _, c := range r.Content {
+		if c.Type == "text" {
+			parts = append(parts, c.Text)
+			continue
+		}
+		raw, _ := json.Marshal(c)
+		parts = append(parts, string(raw))
+	}
+	if len(parts) == 0 && r.StructuredContent != nil {
+		raw, _ := json.Marshal(r.StructuredContent)
+		return string(raw)
+	}
+	return strings.Join(parts, "\n")
+}
+
+type initializeParams struct {
+	ProtocolVersion string         `json:"protocolVersion"`
+	Capabilities    map[string]any `json:"capabilities"`
+	ClientInfo      Implementation `json:"clientInfo"`
+}
+
+type initializeResult struct {
+	ProtocolVersion string         `json:"protocolVersion"`
+	Capabilities    map[string]any `json:"capabilities"`
+	ServerInfo      Implementation `json:"serverInfo"`
+	Instructions    string         `json:"instructions,omitempty"`
+}
+
+type listToolsParams struct {
+	Cursor string `json:"cursor,omitempty"`
+}
+
+type listToolsResult struct {
+	Tools      []Tool `json:"tools"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+type callToolParams struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+}
@@ -0,0 +1,401 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/MetaDiv-AI/llm"
+	"github.com/MetaDiv-AI/logger"
+	"go.uber.org/zap"
+)
+
+// ChatToolName is the name of the tool a Server exposes.
+const ChatToolName = "chat"
+
+// Prompt is a curated prompt a Server exposes through prompts/list and prompts/get and that
+// callers of the chat tool can select by name. Message contents may reference arguments as
+// {{name}}.
+type Prompt struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+	// Messages precede the caller's message; system messages are allowed.
+	Messages []llm.Message `json:"messages"`
+	// Model, if set, is used by chat calls selecting this prompt that do not name a model.
+	Model string `json:"model,omitempty"`
+}
+
+// PromptArgument declares an argument of a Prompt.
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// ServerConfig configures a Server.
+type ServerConfig struct {
+	// Client answers chat tool calls; Client.Chat is required.
+	Client *llm.Client
+	// Info identifies the server to clients.
+	Info Implementation
+	// Instructions are sent to clients during initialization.
+	Instructions string
+	// Model is the default model for chat calls.
+	Model string
+	// Models, if set, restricts the models callers may request.
+	Models []string
+	// Prompts are the curated prompts offered to clients.
+	Prompts []Prompt
+	// Logger, if set, logs failed chat calls.
+	Logger logger.Logger
+}
+
+// Server exposes a ChatProvider-backed chat tool and curated prompts over MCP.
+type Server struct {
+	cfg     ServerConfig
+	prompts map[string]*Prompt
+}
+
+// NewServer returns a Server for cfg.
+func NewServer(cfg ServerConfig) *Server {
+	if cfg.Info.Name == "" {
+		cfg.Info = Implementation{Name: "llm-mcp", Version: DefaultClientInfo.Version}
+	}
+	s := &Server{cfg: cfg, prompts: make(map[string]*Prompt, len(cfg.Prompts))}
+	for i := range cfg.Prompts {
+		s.prompts[cfg.Prompts[i].Name] = &cfg.Prompts[i]
+	}
+	return s
+}
+
+// ServeStdio serves a single client over the process's stdin and stdout until stdin is
+// closed or ctx is done.
+func (s *Server) ServeStdio(ctx context.Context) error {
+	return s.Serve(ctx, NewStreamTransport(os.Stdin, os.Stdout))
+}
+
+// errRequestCancelled is the cancellation cause of requests cancelled by the peer.
+var errRequestCancelled = errors.New("mcp: request cancelled by peer")
+
+// Serve answers requests arriving on t until the peer disconnects or ctx is done, then closes t
+// once in-flight requests have been canceled.
+// Requests are handled concurrently; notifications/cancelled cancels an in-flight tool call,
+// which is then left unanswered.
+func (s *Server) Serve(ctx context.Context, t Transport) error {
+	ctx, cancel := context.WithCancel(ctx)
+	// Closing t unblocks Receive when ctx is done.
+	context.AfterFunc(ctx, func() { t.Close() })
+
+	var (
+		mu       sync.Mutex
+		inflight = map[string]context.CancelCauseFunc{}
+		wg       sync.WaitGroup
+	)
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+	for {
+		msg, err := t.Receive()
+		if errors.Is(err, errInvalidMessage) {
+			t.Send(ctx, &RPCMessage{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &Error{Code: CodeParseError, Message: err.Error()}})
+			continue
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, ErrTransportClosed) || ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		switch {
+		case msg.isRequest():
+			id := string(msg.ID)
+			reqCtx, reqCancel := context.WithCancelCause(ctx)
+			mu.Lock()
+			inflight[id] = reqCancel
+			mu.Unlock()
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				resp := s.handle(reqCtx, msg)
+				mu.Lock()
+				delete(inflight, id)
+				mu.Unlock()
+				// The peer has given up on a cancelled request and expects no reply.
+				if context.Cause(reqCtx) == errRequestCancelled {
+					return
+				}
+				reqCancel(nil)
+				t.Send(ctx, resp)
+			}()
+		case msg.Method == "notifications/cancelled":
+			var p struct {
+				RequestID json.RawMessage `json:"requestId"`
+			}
+			json.Unmarshal(msg.Params, &p)
+			mu.Lock()
+			if cancel := inflight[string(p.RequestID)]; cancel != nil {
+				cancel(errRequestCancelled)
+			}
+			mu.Unlock()
+		}
+	}
+}
+
+// handle answers one request.
+func (s *Server) handle(ctx context.Context, req *RPCMessage) *RPCMessage {
+	resp := &RPCMessage{JSONRPC: "2.0", ID: req.ID}
+	var (
+		result any
+		err    error
+	)
+	switch req.Method {
+	case "initialize":
+		result = initializeResult{
+			ProtocolVersion: ProtocolVersion,
+			Capabilities:    map[string]any{"tools": map[string]any{}, "prompts": map[string]any{}},
+			ServerInfo:      s.cfg.Info,
+			Instructions:    s.cfg.Instructions,
+		}
+	case "ping":
+		result = struct{}{}
+	case "tools/list":
+		result = listToolsResult{Tools: []Tool{s.chatTool()}}
+	case "tools/call":
+		result, err = s.callTool(ctx, req.Params)
+	case "prompts/list":
+		result = listPromptsResult{Prompts: s.promptInfos()}
+	case "prompts/get":
+		result, err = s.getPrompt(req.Params)
+	default:
+		err = &Error{Code: CodeMethodNotFound, Message: "method not found: " + req.Method}
+	}
+	if err != nil {
+		var rpcErr *Error
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &Error{Code: CodeInternalError, Message: err.Error()}
+		}
+		resp.Error = rpcErr
+		return resp
+	}
+	resp.Result, err = json.Marshal(result)
+	if err != nil {
+		resp.Error = &Error{Code: CodeInternalError, Message: err.Error()}
+	}
+	return resp
+}
+
+// chatArgs are the arguments of the chat tool.
+type chatArgs struct {
+	Message     string            `json:"message" jsonschema:"description=The user message to send"`
+	Prompt      string            `json:"prompt,omitempty" jsonschema:"description=Name of a curated prompt whose messages precede the message"`
+	Arguments   map[string]string `json:"arguments,omitempty" jsonschema:"description=Values for the prompt's {{argument}} placeholders"`
+	Model       string            `json:"model,omitempty" jsonschema:"description=Model to use instead of the default"`
+	Temperature *float64          `json:"temperature,omitempty"`
+	MaxTokens   *int              `json:"max_tokens,omitempty"`
+}
+
+func (s *Server) chatTool() Tool {
+	schema := llm.SchemaFor[chatArgs]()
+	props := schema["properties"].(map[string]any)
+	if names := s.promptNames(); len(names) > 0 {
+		props["prompt"].(map[string]any)["enum"] = names
+	}
+	if len(s.cfg.Models) > 0 {
+		props["model"].(map[string]any)["enum"] = s.cfg.Models
+	}
+	return Tool{
+		Name:        ChatToolName,
+		Description: "Send a message to a language model, optionally preceded by a curated prompt, and return its reply.",
+		InputSchema: schema,
+	}
+}
+
+// callTool runs the chat tool. Invalid arguments are protocol errors; model failures are
+// reported as tool results with isError set, so the calling model can see them.
+func (s *Server) callTool(ctx context.Context, params json.RawMessage) (*CallToolResult, error) {
+	var p callToolParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, &Error{Code: CodeInvalidParams, Message: err.Error()}
+	}
+	if p.Name != ChatToolName {
+		return nil, &Error{Code: CodeInvalidParams, Message: fmt.Sprintf("unknown tool %q", p.Name)}
+	}
+	var args chatArgs
+	if len(p.Arguments) > 0 {
+		if err := json.Unmarshal(p.Arguments, &args); err != nil {
+			return nil, &Error{Code: CodeInvalidParams, Message: "invalid arguments: " + err.Error()}
+		}
+	}
+	req, err := s.chatRequest(args)
+	if err != nil {
+		return nil, &Error{Code: CodeInvalidParams, Message: err.Error()}
+	}
+	resp, err := s.cfg.Client.Chat.Create(ctx, req)
+	if err != nil {
+		if s.cfg.Logger != nil {
+			s.cfg.Logger.Warn("mcp: chat failed", zap.String("model", req.Model), zap.Error(err))
+		}
+		return &CallToolResult{Content: []Content{{Type: "text", Text: err.Error()}}, IsError: true}, nil
+	}
+	res := &CallToolResult{Content: []Content{{Type: "text", Text: responseText(resp)}}}
+	res.Meta = map[string]any{"model": req.Model}
+	if resp.Model != "" {
+		res.Meta["model"] = resp.Model
+	}
+	if resp.Usage != nil {
+		res.Meta["usage"] = resp.Usage
+	}
+	return res, nil
+}
+
+// chatRequest builds and validates the ChatRequest for args.
+func (s *Server) chatRequest(args chatArgs) (*llm.ChatRequest, error) {
+	req := &llm.ChatRequest{Model: args.Model, Temperature: args.Temperature, MaxTokens: args.MaxTokens}
+	if args.Prompt != "" {
+		prompt, ok := s.prompts[args.Prompt]
+		if !ok {
+			return nil, fmt.Errorf("unknown prompt %q", args.Prompt)
+		}
+		msgs, err := prompt.render(args.Arguments)
+		if err != nil {
+			return nil, err
+		}
+		req.Messages = msgs
+		if req.Model == "" {
+			req.Model = prompt.Model
+		}
+	}
+	if req.Model == "" {
+		req.Model = s.cfg.Model
+	}
+	if len(s.cfg.Models) > 0 && req.Model != "" && !slices.Contains(s.cfg.Models, req.Model) {
+		return nil, fmt.Errorf("model %q is not allowed", req.Model)
+	}
+	if args.Message != "" {
+		req.Messages = append(req.Messages, llm.Message{Role: "user", Content: args.Message})
+	}
+	if err := llm.ValidateChatRequest(req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func responseText(resp *llm.ChatResponse) string {
+	if len(resp.Choices) == 0 || resp.Choices[0].Message == nil {
+		return ""
+	}
+	switch c := resp.Choices[0].Message.Content.(type) {
+	case string:
+		return c
+	case nil:
+		return ""
+	default:
+		raw, _ := json.Marshal(c)
+		return string(raw)
+	}
+}
+
+// render returns the prompt's messages with {{name}} placeholders replaced by args.
+func (p *Prompt) render(args map[string]string) ([]llm.Message, error) {
+	for _, a := range p.Arguments {
+		if a.Required && args[a.Name] == "" {
+			return nil, fmt.Errorf("prompt %q: missing required argument %q", p.Name, a.Name)
+		}
+	}
+	pairs := make([]string, 0, 2*len(args))
+	for k, v := range args {
+		pairs = append(pairs, "{{"+k+"}}", v)
+	}
+	r := strings.NewReplacer(pairs...)
+	msgs := make([]llm.Message, len(p.Messages))
+	for i, m := range p.Messages {
+		if text, ok := m.Content.(string); ok {
+			m.Content = r.Replace(text)
+		}
+		msgs[i] = m
+	}
+	return msgs, nil
+}
+
+type promptInfo struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+}
+
+type listPromptsResult struct {
+	Prompts []promptInfo `json:"prompts"`
+}
+
+type getPromptParams struct {
+	Name      string            `json:"name"`
+	Arguments map[string]string `json:"arguments,omitempty"`
+}
+
+type promptMessage struct {
+	Role    string  `json:"role"`
+	Content Content `json:"content"`
+}
+
+type getPromptResult struct {
+	Description string          `json:"description,omitempty"`
+	Messages    []promptMessage `json:"messages"`
+}
+
+func (s *Server) promptNames() []string {
+	names := make([]string, 0, len(s.prompts))
+	for name := range s.prompts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *Server) promptInfos() []promptInfo {
+	infos := make([]promptInfo, 0, len(s.prompts))
+	for _, name := range s.promptNames() {
+		p := s.prompts[name]
+		infos = append(infos, promptInfo{Name: p.Name, Description: p.Description, Arguments: p.Arguments})
+	}
+	return infos
+}
+
+// getPrompt renders a prompt for prompts/get. MCP prompts only carry user and assistant
+// messages, so system messages are sent with the user role.
+func (s *Server) getPrompt(params json.RawMessage) (*getPromptResult, error) {
+	var p getPromptParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, &Error{Code: CodeInvalidParams, Message: err.Error()}
+	}
+	prompt, ok := s.prompts[p.Name]
+	if !ok {
+		return nil, &Error{Code: CodeInvalidParams, Message: fmt.Sprintf("unknown prompt %q", p.Name)}
+	}
+	msgs, err := prompt.render(p.Arguments)
+	if err != nil {
+		return nil, &Error{Code: CodeInvalidParams, Message: err.Error()}
+	}
+	res := &getPromptResult{Description: prompt.Description, Messages: make([]promptMessage, 0, len(msgs))}
+	for _, m := range msgs {
+		role := m.Role
+		if role != "assistant" {
+			role = "user"
+		}
+		text, ok := m.Content.(string)
+		if !ok {
+			raw, _ := json.Marshal(m.Content)
+			text = string(raw)
+		}
+		res.Messages = append(res.Messages, promptMessage{Role: role, Content: Content{Type: "text", Text: text}})
+	}
+	return res, nil
+}
@@ -0,0 +1,245 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MetaDiv-AI/llm"
+)
+
+var testPrompts = []Prompt{{
+	Name:        "review",
+	Description: "Code review",
+	Arguments:   []PromptArgument{{Name: "lang", Required: true}},
+	Messages:    []llm.Message{{Role: "system", Content: "You review {{lang}} code."}},
+	Model:       "reviewer",
+}}
+
+// serveTest connects a Client to a Server for chat over in-process pipes.
+func serveTest(t *testing.T, chat llm.ChatProvider) *Client {
+	t.Helper()
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+	s := NewServer(ServerConfig{
+		Client:       &llm.Client{Chat: chat},
+		Info:         Implementation{Name: "test-server", Version: "1"},
+		Instructions: "curated models",
+		Model:        "default",
+		Models:       []string{"default", "reviewer"},
+		Prompts:      testPrompts,
+	})
+	done := make(chan error, 1)
+	go func() { done <- s.Serve(context.Background(), NewStreamTransport(serverR, serverW)) }()
+	c, err := Connect(context.Background(), NewStreamTransport(clientR, clientW), ClientOptions{})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(func() {
+		c.Close()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("Serve: %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Error("Serve did not return after the client closed")
+		}
+	})
+	return c
+}
+
+func TestServer_Chat(t *testing.T) {
+	var got *llm.ChatRequest
+	chat := &llm.MockChatProvider{CreateFunc: func(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+		got = req
+		return &llm.ChatResponse{
+			Model:   req.Model,
+			Choices: []llm.Choice{{Message: &llm.Message{Role: "assistant", Content: "LGTM"}}},
+			Usage:   &llm.Usage{PromptTokens: 7, CompletionTokens: 2, TotalTokens: 9},
+		}, nil
+	}}
+	c := serveTest(t, chat)
+	ctx := context.Background()
+	if c.ServerInfo.Name != "test-server" || c.Instructions != "curated models" {
+		t.Errorf("server info = %+v", c.ServerInfo)
+	}
+
+	tools, err := c.ListTools(ctx)
+	if err != nil || len(tools) != 1 || tools[0].Name != ChatToolName {
+		t.Fatalf("tools = %+v, %v", tools, err)
+	}
+	props := tools[0].InputSchema["properties"].(map[string]any)
+	if enum := props["prompt"].(map[string]any)["enum"]; !reflect.DeepEqual(enum, []any{"review"}) {
+		t.Errorf("prompt enum = %v", enum)
+	}
+	if enum := props["model"].(map[string]any)["enum"]; !reflect.DeepEqual(enum, []any{"default", "reviewer"}) {
+		t.Errorf("model enum = %v", enum)
+	}
+
+	res, err := c.CallTool(ctx, ChatToolName, map[string]any{"message": "func f() {}", "prompt": "review", "arguments": map[string]string{"lang": "Go"}})
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if res.IsError || res.Text() != "LGTM" {
+		t.Errorf("result = %+v", res)
+	}
+	want := []llm.Message{{Role: "system", Content: "You review Go code."}, {Role: "user", Content: "func f() {}"}}
+	if got.Model != "reviewer" || !reflect.DeepEqual(got.Messages, want) {
+		t.Errorf("request = %+v", got)
+	}
+	var usage llm.Usage
+	raw, _ := json.Marshal(res.Meta["usage"])
+	json.Unmarshal(raw, &usage)
+	if usage != (llm.Usage{PromptTokens: 7, CompletionTokens: 2, TotalTokens: 9}) || res.Meta["model"] != "reviewer" {
+		t.Errorf("meta = %v", res.Meta)
+	}
+
+	if _, err := c.CallTool(ctx, ChatToolName, map[string]any{"message": "hi"}); err != nil || got.Model != "default" {
+		t.Errorf("default model = %q, err = %v", got.Model, err)
+	}
+}
+
+func TestServer_ChatErrors(t *testing.T) {
+	upstream := &llm.APIError{Provider: llm.ProviderOpenAI, StatusCode: 503, Message: "overloaded"}
+	chat := &llm.MockChatProvider{CreateFunc: func(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+		return nil, upstream
+	}}
+	c := serveTest(t, chat)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		args map[string]any
+		want string
+	}{
+		{"validation", map[string]any{}, "messages"},
+		{"unknown prompt", map[string]any{"message": "hi", "prompt": "nope"}, "unknown prompt"},
+		{"missing argument", map[string]any{"message": "hi", "prompt": "review"}, "lang"},
+		{"model not allowed", map[string]any{"message": "hi", "model": "other"}, "not allowed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rpcErr *Error
+			_, err := c.CallTool(ctx, ChatToolName, tt.args)
+			if !errors.As(err, &rpcErr) || rpcErr.Code != CodeInvalidParams || !strings.Contains(rpcErr.Message, tt.want) {
+				t.Errorf("err = %v, want invalid params mentioning %q", err, tt.want)
+			}
+		})
+	}
+
+	res, err := c.CallTool(ctx, ChatToolName, map[string]any{"message": "hi"})
+	if err != nil || !res.IsError || !strings.Contains(res.Text(), "overloaded") {
+		t.Errorf("provider failure = %+v, %v", res, err)
+	}
+}
+
+func TestServer_Prompts(t *testing.T) {
+	c := serveTest(t, &llm.MockChatProvider{})
+	ctx := context.Background()
+
+	var list listPromptsResult
+	if err := c.call(ctx, "prompts/list", nil, &list); err != nil {
+		t.Fatalf("prompts/list: %v", err)
+	}
+	if len(list.Prompts) != 1 || list.Prompts[0].Name != "review" || !list.Prompts[0].Arguments[0].Required {
+		t.Errorf("prompts = %+v", list.Prompts)
+	}
+
+	var got getPromptResult
+	if err := c.call(ctx, "prompts/get", getPromptParams{Name: "review", Arguments: map[string]string{"lang": "Rust"}}, &got); err != nil {
+		t.Fatalf("prompts/get: %v", err)
+	}
+	want := []promptMessage{{Role: "user", Content: Content{Type: "text", Text: "You review Rust code."}}}
+	if !reflect.DeepEqual(got.Messages, want) {
+		t.Errorf("messages = %+v", got.Messages)
+	}
+
+	var rpcErr *Error
+	if err := c.call(ctx, "resources/list", nil, nil); !errors.As(err, &rpcErr) || rpcErr.Code != CodeMethodNotFound {
+		t.Errorf("unknown method err = %v", err)
+	}
+}
+
+func TestServer_Cancel(t *testing.T) {
+	canceled := make(chan struct{})
+	chat := &llm.MockChatProvider{CreateFunc: func(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+		<-ctx.Done()
+		close(canceled)
+		return nil, ctx.Err()
+	}}
+	c := serveTest(t, chat)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := c.CallTool(ctx, ChatToolName, map[string]any{"message": "hi"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v", err)
+	}
+	select {
+	case <-canceled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not cancel the chat call")
+	}
+}
+
+func TestServer_ParseError(t *testing.T) {
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+	go NewServer(ServerConfig{Client: &llm.Client{Chat: &llm.MockChatProvider{}}}).Serve(context.Background(), NewStreamTransport(serverR, serverW))
+	defer clientW.Close()
+
+	lines := bufio.NewScanner(clientR)
+	// io.Pipe is unbuffered, so write while reading the responses.
+	go func() {
+		io.WriteString(clientW, "not json\n")
+		io.WriteString(clientW, `{"jsonrpc":"2.0","id":1,"method":"ping"}`+"\n")
+	}()
+	var msgs []RPCMessage
+	for len(msgs) < 2 && lines.Scan() {
+		var msg RPCMessage
+		json.Unmarshal(lines.Bytes(), &msg)
+		msgs = append(msgs, msg)
+	}
+	if len(msgs) != 2 || msgs[0].Error == nil || msgs[0].Error.Code != CodeParseError || string(msgs[1].ID) != "1" || string(msgs[1].Result) != "{}" {
+		t.Errorf("responses = %+v", msgs)
+	}
+}
+
+func TestServer_CancelledNotAnswered(t *testing.T) {
+	canceled := make(chan struct{})
+	chat := &llm.MockChatProvider{CreateFunc: func(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+		<-ctx.Done()
+		close(canceled)
+		return nil, ctx.Err()
+	}}
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+	go NewServer(ServerConfig{Client: &llm.Client{Chat: chat}, Model: "m"}).Serve(context.Background(), NewStreamTransport(serverR, serverW))
+	defer clientW.Close()
+
+	lines := bufio.NewScanner(clientR)
+	go func() {
+		io.WriteString(clientW, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"chat","arguments":{"message":"hi"}}}`+"\n")
+		io.WriteString(clientW, `{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":1}}`+"\n")
+		<-canceled
+		io.WriteString(clientW, `{"jsonrpc":"2.0","id":2,"method":"ping"}`+"\n")
+		time.Sleep(20 * time.Millisecond)
+		io.WriteString(clientW, `{"jsonrpc":"2.0","id":3,"method":"ping"}`+"\n")
+	}()
+	for lines.Scan() {
+		var msg RPCMessage
+		json.Unmarshal(lines.Bytes(), &msg)
+		if string(msg.ID) == "1" {
+			t.Fatalf("cancelled request was answered: %s", lines.Bytes())
+		}
+		if string(msg.ID) == "3" {
+			return
+		}
+	}
+	t.Fatal("no answer to ping")
+}
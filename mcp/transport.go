@@ -0,0 +1,328 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrTransportClosed is returned by Transport.Receive and Send after Close.
+var ErrTransportClosed = errors.New("mcp: transport closed")
+
+// errInvalidMessage is returned by Receive for a line that is not a JSON-RPC message. The
+// transport remains usable.
+var errInvalidMessage = errors.New("mcp: invalid message")
+
+// Transport carries JSON-RPC messages between a client and a server.
+type Transport interface {
+	// Send delivers one message to the peer.
+	Send(ctx context.Context, msg *RPCMessage) error
+	// Receive blocks until the next message from the peer arrives. It returns io.EOF or
+	// ErrTransportClosed when no more messages will arrive.
+	Receive() (*RPCMessage, error)
+	Close() error
+}
+
+// NewStreamTransport returns a Transport exchanging newline-delimited JSON messages over r and
+// w, as the MCP stdio transport does. Close closes w and r.
+func NewStreamTransport(r io.ReadCloser, w io.WriteCloser) Transport {
+	return &streamTransport{r: r, w: w, dec: bufio.NewReader(r)}
+}
+
+type streamTransport struct {
+	r   io.ReadCloser
+	w   io.WriteCloser
+	dec *bufio.Reader
+
+	mu        sync.Mutex // serializes writes
+	closeOnce sync.Once
+	closeErr  error
+	closer    func() error // extra cleanup, e.g. waiting for a subprocess
+}
+
+func (t *streamTransport) Send(ctx context.Context, msg *RPCMessage) error {
+	raw, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, err = t.w.Write(append(raw, '\n'))
+	return err
+}
+
+func (t *streamTransport) Receive() (*RPCMessage, error) {
+	for {
+		line, err := t.dec.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			var msg RPCMessage
+			if jerr := json.Unmarshal(line, &msg); jerr != nil {
+				return nil, fmt.Errorf("%w: %v", errInvalidMessage, jerr)
+			}
+			return &msg, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (t *streamTransport) Close() error {
+	t.closeOnce.Do(func() {
+		if t.closer != nil {
+			t.closeErr = t.closer()
+			return
+		}
+		t.closeErr = errors.Join(t.w.Close(), t.r.Close())
+	})
+	return t.closeErr
+}
+
+// commandCloseTimeout is how long Close waits for a server process to exit after its stdin
+// is closed before killing it.
+const commandCloseTimeout = 5 * time.Second
+
+// NewCommandTransport starts cmd and speaks the MCP stdio transport over its stdin and stdout.
+// cmd's Stderr is left as configured. Close closes stdin and waits for the process, killing it
+// if it does not exit within a few seconds.
+func NewCommandTransport(cmd *exec.Cmd) (Transport, error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	t := &streamTransport{r: stdout, w: stdin, dec: bufio.NewReader(stdout)}
+	t.closer = func() error {
+		stdin.Close()
+		done := make(chan error, 1)
+		go func() { done <- cmd.Wait() }()
+		select {
+		case err := <-done:
+			return err
+		case <-time.After(commandCloseTimeout):
+			cmd.Process.Kill()
+			return <-done
+		}
+	}
+	return t, nil
+}
+
+// NewHTTPTransport returns a Transport for the MCP streamable HTTP transport at url. Each
+// message is POSTed; responses may be JSON or an SSE stream. The Mcp-Session-Id header
+// issued by the server is sent on later requests, and Close ends the session with DELETE.
+// A nil client uses http.DefaultClient.
+func NewHTTPTransport(url string, client *http.Client) Transport {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &httpTransport{url: url, client: client, ctx: ctx, cancel: cancel, incoming: make(chan *RPCMessage, 16), done: make(chan struct{})}
+}
+
+type httpTransport struct {
+	url    string
+	client *http.Client
+
+	mu        sync.Mutex
+	sessionID string
+
+	// ctx is canceled by Close to abort requests and SSE streams still being read.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	incoming  chan *RPCMessage
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+func (t *httpTransport) Send(ctx context.Context, msg *RPCMessage) error {
+	select {
+	case <-t.done:
+		return ErrTransportClosed
+	default:
+	}
+	raw, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(t.ctx, cancel)
+	release := func() {
+		stop()
+		cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(raw))
+	if err != nil {
+		release()
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	t.setSession(req)
+	resp, err := t.client.Do(req)
+	if err != nil {
+		release()
+		return err
+	}
+	if id := resp.Header.Get("Mcp-Session-Id"); id != "" {
+		t.mu.Lock()
+		t.sessionID = id
+		t.mu.Unlock()
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer release()
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("mcp: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch {
+	case resp.StatusCode == http.StatusAccepted || resp.ContentLength == 0:
+		resp.Body.Close()
+		release()
+		return nil
+	case mediaType == "text/event-stream":
+		// The stream may stay open for server messages; read it in the background.
+		t.wg.Add(1)
+		go func() {
+			defer t.wg.Done()
+			defer release()
+			defer resp.Body.Close()
+			t.readSSE(resp.Body)
+		}()
+		return nil
+	default:
+		defer release()
+		defer resp.Body.Close()
+		return t.readJSON(resp.Body)
+	}
+}
+
+func (t *httpTransport) setSession(req *http.Request) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.sessionID != "" {
+		req.Header.Set("Mcp-Session-Id", t.sessionID)
+	}
+}
+
+// readJSON delivers a single message or a batch.
+func (t *httpTransport) readJSON(body io.Reader) error {
+	raw, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return nil
+	}
+	var msgs []*RPCMessage
+	if raw[0] == '[' {
+		err = json.Unmarshal(raw, &msgs)
+	} else {
+		var msg RPCMessage
+		err = json.Unmarshal(raw, &msg)
+		msgs = append(msgs, &msg)
+	}
+	if err != nil {
+		return fmt.Errorf("mcp: invalid response: %w", err)
+	}
+	for _, msg := range msgs {
+		if !t.deliver(msg) {
+			return ErrTransportClosed
+		}
+	}
+	return nil
+}
+
+// readSSE delivers the data of each SSE event as a message until the stream ends.
+func (t *httpTransport) readSSE(body io.Reader) {
+	sc := bufio.NewScanner(body)
+	sc.Buffer(make([]byte, 64*1024), 16<<20)
+	var data []string
+	flush := func() bool {
+		if len(data) == 0 {
+			return true
+		}
+		var msg RPCMessage
+		err := json.Unmarshal([]byte(strings.Join(data, "\n")), &msg)
+		data = data[:0]
+		if err != nil {
+			return true
+		}
+		return t.deliver(&msg)
+	}
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case line == "":
+			if !flush() {
+				return
+			}
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	flush()
+}
+
+func (t *httpTransport) deliver(msg *RPCMessage) bool {
+	select {
+	case t.incoming <- msg:
+		return true
+	case <-t.done:
+		return false
+	}
+}
+
+func (t *httpTransport) Receive() (*RPCMessage, error) {
+	select {
+	case msg := <-t.incoming:
+		return msg, nil
+	case <-t.done:
+		return nil, ErrTransportClosed
+	}
+}
+
+func (t *httpTransport) Close() error {
+	var err error
+	t.closeOnce.Do(func() {
+		close(t.done)
+		t.cancel()
+		t.mu.Lock()
+		session := t.sessionID
+		t.mu.Unlock()
+		if session != "" {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			req, rerr := http.NewRequestWithContext(ctx, http.MethodDelete, t.url, nil)
+			if rerr != nil {
+				err = rerr
+				return
+			}
+			req.Header.Set("Mcp-Session-Id", session)
+			if resp, derr := t.client.Do(req); derr == nil {
+				resp.Body.Close()
+			}
+		}
+		t.wg.Wait()
+	})
+	return err
+}
@@ -1,5 +1,11 @@
 package llm
 
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
 // TextMessage returns a text-only message for the given role and content.
 func TextMessage(role, text string) Message {
 	return Message{Role: role, Content: text}
@@ -13,3 +19,62 @@ func MultimodalMessage(role string, parts []ContentPart) Message {
 	}
 	return Message{Role: role, Content: parts}
 }
+
+// contentParts normalizes Message.Content into content parts.
+// A string becomes a single text part; []any and []map[string]any (e.g. decoded from JSON)
+// are converted through a JSON round trip. A nil content yields no parts.
+func contentParts(content any) ([]ContentPart, error) {
+	switch v := content.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []ContentPart{{Type: "text", Text: v}}, nil
+	case []ContentPart:
+		return v, nil
+	case []any, []map[string]any:
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		var parts []ContentPart
+		if err := json.Unmarshal(raw, &parts); err != nil {
+			return nil, err
+		}
+		return parts, nil
+	default:
+		return nil, fmt.Errorf("unsupported content type %T", content)
+	}
+}
+
+// contentText returns the text of content: the string itself, or the
+// concatenation of all text parts.
+func contentText(content any) string {
+	if s, ok := content.(string); ok {
+		return s
+	}
+	parts, _ := contentParts(content)
+	var b strings.Builder
+	for _, p := range parts {
+		if p.Type == "text" {
+			b.WriteString(p.Text)
+		}
+	}
+	return b.String()
+}
+
+// parseDataURL splits a "data:<media type>;base64,<data>" URL into its media type and base64 payload.
+func parseDataURL(u string) (mediaType, data string, ok bool) {
+	rest, found := strings.CutPrefix(u, "data:")
+	if !found {
+		return "", "", false
+	}
+	meta, data, found := strings.Cut(rest, ",")
+	if !found {
+		return "", "", false
+	}
+	mediaType, found = strings.CutSuffix(meta, ";base64")
+	if !found {
+		return "", "", false
+	}
+	return mediaType, data, true
+}
@@ -0,0 +1,45 @@
+package llm
+
+// ChatMiddleware wraps a ChatProvider to add cross-cutting behavior such as logging,
+// metrics, caching or retries. The returned provider should wrap both Create and CreateStream.
+type ChatMiddleware func(ChatProvider) ChatProvider
+
+// EmbeddingMiddleware wraps an EmbeddingProvider to add cross-cutting behavior.
+type EmbeddingMiddleware func(EmbeddingProvider) EmbeddingProvider
+
+// ChainChat wraps p with mw. The first middleware is the outermost: it sees each
+// request first and each response last.
+func ChainChat(p ChatProvider, mw ...ChatMiddleware) ChatProvider {
+	for i := len(mw) - 1; i >= 0; i-- {
+		if mw[i] != nil {
+			p = mw[i](p)
+		}
+	}
+	return p
+}
+
+// ChainEmbedding wraps p with mw. The first middleware is the outermost.
+func ChainEmbedding(p EmbeddingProvider, mw ...EmbeddingMiddleware) EmbeddingProvider {
+	for i := len(mw) - 1; i >= 0; i-- {
+		if mw[i] != nil {
+			p = mw[i](p)
+		}
+	}
+	return p
+}
+
+// WithChatMiddleware appends middleware applied by NewClient to Client.Chat.
+// Middleware is applied in order, the first being the outermost.
+func WithChatMiddleware(mw ...ChatMiddleware) Option {
+	return func(c *config) {
+		c.ChatMiddleware = append(c.ChatMiddleware, mw...)
+	}
+}
+
+// WithEmbeddingMiddleware appends middleware applied by NewClient to Client.Embeddings.
+// Middleware is applied in order, the first being the outermost.
+func WithEmbeddingMiddleware(mw ...EmbeddingMiddleware) Option {
+	return func(c *config) {
+		c.EmbeddingMiddleware = append(c.EmbeddingMiddleware, mw...)
+	}
+}
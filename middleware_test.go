@@ -0,0 +1,80 @@
+package llm
+
+import (
+	"context"
+	"testing"
+)
+
+type recordingChat struct {
+	next  ChatProvider
+	name  string
+	trace *[]string
+}
+
+func (r *recordingChat) Create(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	*r.trace = append(*r.trace, r.name+":create")
+	return r.next.Create(ctx, req)
+}
+
+func (r *recordingChat) CreateStream(ctx context.Context, req *ChatRequest) (StreamReader, error) {
+	*r.trace = append(*r.trace, r.name+":stream")
+	return r.next.CreateStream(ctx, req)
+}
+
+func recordingMiddleware(name string, trace *[]string) ChatMiddleware {
+	return func(next ChatProvider) ChatProvider {
+		return &recordingChat{next: next, name: name, trace: trace}
+	}
+}
+
+func TestWithChatMiddleware_Order(t *testing.T) {
+	const name Provider = "test-middleware"
+	RegisterProvider(name, func(Config) (*Client, error) {
+		return &Client{Chat: &MockChatProvider{}, Embeddings: &MockEmbeddingProvider{}}, nil
+	})
+	t.Cleanup(func() {
+		registryMu.Lock()
+		delete(registry, name)
+		registryMu.Unlock()
+	})
+
+	var trace []string
+	client, err := NewClient(name, WithChatMiddleware(recordingMiddleware("outer", &trace), recordingMiddleware("inner", &trace)))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	req := &ChatRequest{Model: "m", Messages: []Message{{Role: "user", Content: "hi"}}}
+	if _, err := client.Chat.Create(context.Background(), req); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := client.Chat.CreateStream(context.Background(), req); err != nil {
+		t.Fatalf("CreateStream: %v", err)
+	}
+	want := []string{"outer:create", "inner:create", "outer:stream", "inner:stream"}
+	if len(trace) != len(want) {
+		t.Fatalf("trace = %v, want %v", trace, want)
+	}
+	for i := range want {
+		if trace[i] != want[i] {
+			t.Errorf("trace = %v, want %v", trace, want)
+			break
+		}
+	}
+}
+
+func TestChainEmbedding(t *testing.T) {
+	calls := 0
+	mw := func(next EmbeddingProvider) EmbeddingProvider {
+		return &MockEmbeddingProvider{CreateFunc: func(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error) {
+			calls++
+			return next.Create(ctx, req)
+		}}
+	}
+	p := ChainEmbedding(&MockEmbeddingProvider{}, mw, nil, mw)
+	if _, err := p.Create(context.Background(), &EmbeddingRequest{Model: "m", Input: "x"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("calls = %d, want 2", calls)
+	}
+}
@@ -9,6 +9,11 @@ import (
 type MockChatProvider struct {
 	CreateFunc       func(context.Context, *ChatRequest) (*ChatResponse, error)
 	CreateStreamFunc func(context.Context, *ChatRequest) (StreamReader, error)
+	// StreamChunks is streamed by CreateStream when CreateStreamFunc is nil. Include a
+	// Choice with a ChoiceError to simulate a mid-stream provider error.
+	StreamChunks []*StreamChunk
+	// StrictStream wraps streams with NewStrictStreamReader, as WithStrictStream does.
+	StrictStream bool
 }
 
 func (m *MockChatProvider) Create(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
@@ -19,10 +24,43 @@ func (m *MockChatProvider) Create(ctx context.Context, req *ChatRequest) (*ChatR
 }
 
 func (m *MockChatProvider) CreateStream(ctx context.Context, req *ChatRequest) (StreamReader, error) {
+	var stream StreamReader = &noopStreamReader{}
 	if m.CreateStreamFunc != nil {
-		return m.CreateStreamFunc(ctx, req)
+		var err error
+		if stream, err = m.CreateStreamFunc(ctx, req); err != nil {
+			return nil, err
+		}
+	} else if m.StreamChunks != nil {
+		stream = &MockStreamReader{Chunks: m.StreamChunks}
 	}
-	return &noopStreamReader{}, nil
+	if m.StrictStream {
+		stream = NewStrictStreamReader(stream)
+	}
+	return stream, nil
+}
+
+// MockStreamReader is a StreamReader that returns Chunks in order, then Err (io.EOF if nil).
+type MockStreamReader struct {
+	Chunks []*StreamChunk
+	Err    error
+	Closed bool
+	pos    int
+}
+
+func (m *MockStreamReader) Next() (*StreamChunk, error) {
+	if m.pos < len(m.Chunks) {
+		m.pos++
+		return m.Chunks[m.pos-1], nil
+	}
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	return nil, io.EOF
+}
+
+func (m *MockStreamReader) Close() error {
+	m.Closed = true
+	return nil
 }
 
 // noopStreamReader is a StreamReader that immediately returns EOF.
@@ -0,0 +1,33 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+)
+
+// ndjsonDecoder reads newline-delimited JSON values from a response body.
+type ndjsonDecoder struct {
+	scanner *bufio.Scanner
+}
+
+func newNDJSONDecoder(r io.Reader) *ndjsonDecoder {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), maxSSELineSize)
+	return &ndjsonDecoder{scanner: s}
+}
+
+// Next returns the next non-blank line, or io.EOF when the body is exhausted.
+func (d *ndjsonDecoder) Next() ([]byte, error) {
+	for d.scanner.Scan() {
+		line := bytes.TrimSpace(d.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		return append([]byte(nil), line...), nil
+	}
+	if err := d.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
@@ -0,0 +1,373 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultOllamaBaseURL is the default base URL for a local Ollama server.
+const DefaultOllamaBaseURL = "http://localhost:11434"
+
+type ollamaChat struct {
+	c *caller
+}
+
+type ollamaEmbedding struct {
+	c *caller
+}
+
+// newOllamaClient creates an Ollama client. No API key is required; when one is
+// configured it is sent as a bearer token (e.g. for Ollama behind an authenticating proxy).
+// Without WithBaseURL, the OLLAMA_HOST env var is used if set.
+func newOllamaClient(cfg *config) (*Client, error) {
+	defaultBaseURL := DefaultOllamaBaseURL
+	if host := os.Getenv("OLLAMA_HOST"); host != "" {
+		if !strings.Contains(host, "://") {
+			host = "http://" + host
+		}
+		defaultBaseURL = host
+	}
+	auth := map[string]string{}
+	if cfg.APIKey != "" {
+		auth["Authorization"] = "Bearer " + cfg.APIKey
+	}
+	c := newCaller(ProviderOllama, cfg, defaultBaseURL, auth)
+	return &Client{
+		Chat:       &ollamaChat{c: c},
+		Embeddings: &ollamaEmbedding{c: c},
+	}, nil
+}
+
+func (p *ollamaChat) Create(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	if err := validateChatRequest(req); err != nil {
+		return nil, err
+	}
+	oReq, err := toOllamaRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	var resp ollamaChatResponse
+	if err := p.c.doPost(ctx, "/api/chat", oReq, &resp); err != nil {
+		return nil, err
+	}
+	numTools := 0
+	msg := resp.Message.toLLM(&numTools)
+	for i := range msg.ToolCalls {
+		msg.ToolCalls[i].Index = nil
+	}
+	return &ChatResponse{
+		Object:  "chat.completion",
+		Created: resp.created(),
+		Model:   resp.Model,
+		Choices: []Choice{{Message: msg, FinishReason: ollamaFinishReason(resp.DoneReason, len(msg.ToolCalls) > 0)}},
+		Usage:   resp.usage(),
+	}, nil
+}
+
+func (p *ollamaChat) CreateStream(ctx context.Context, req *ChatRequest) (StreamReader, error) {
+	if err := validateChatRequest(req); err != nil {
+		return nil, err
+	}
+	oReq, err := toOllamaRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	oReq.Stream = true
+	body, err := p.c.doStreamPost(ctx, "/api/chat", oReq)
+	if err != nil {
+		return nil, err
+	}
+	return &ollamaStreamReader{body: body, dec: newNDJSONDecoder(body)}, nil
+}
+
+func (p *ollamaEmbedding) Create(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error) {
+	if err := validateEmbeddingRequest(req); err != nil {
+		return nil, err
+	}
+	inputs, err := embeddingInputs(req.Input)
+	if err != nil {
+		return nil, err
+	}
+	var resp ollamaEmbedResponse
+	if err := p.c.doPost(ctx, "/api/embed", &ollamaEmbedRequest{Model: req.Model, Input: inputs}, &resp); err != nil {
+		return nil, err
+	}
+	out := &EmbeddingResponse{
+		Data:  make([]EmbeddingData, len(resp.Embeddings)),
+		Usage: &EmbeddingUsage{PromptTokens: resp.PromptEvalCount, TotalTokens: resp.PromptEvalCount},
+	}
+	for i, e := range resp.Embeddings {
+		out.Data[i] = EmbeddingData{Object: "embedding", Embedding: e, Index: i}
+	}
+	return out, nil
+}
+
+// ollamaStreamReader reads the NDJSON stream returned by /api/chat. The final line
+// has done=true and carries the finish reason and token counts.
+type ollamaStreamReader struct {
+	body     io.ReadCloser
+	dec      *ndjsonDecoder
+	done     bool
+	numTools int
+}
+
+func (s *ollamaStreamReader) Next() (*StreamChunk, error) {
+	if s.done {
+		return nil, io.EOF
+	}
+	line, err := s.dec.Next()
+	if err != nil {
+		if err == io.EOF {
+			s.done = true
+		}
+		return nil, err
+	}
+	var resp ollamaChatResponse
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return nil, fmt.Errorf("llm: ollama: malformed stream chunk: %w", err)
+	}
+	if resp.Error != "" {
+		return nil, &APIError{Provider: ProviderOllama, Message: resp.Error}
+	}
+	msg := resp.Message.toLLM(&s.numTools)
+	chunk := &StreamChunk{
+		Object:  "chat.completion.chunk",
+		Created: resp.created(),
+		Model:   resp.Model,
+		Choices: []Choice{{Delta: msg}},
+	}
+	if resp.Done {
+		s.done = true
+		chunk.Choices[0].FinishReason = ollamaFinishReason(resp.DoneReason, s.numTools > 0)
+		chunk.Usage = resp.usage()
+	}
+	return chunk, nil
+}
+
+func (s *ollamaStreamReader) Close() error {
+	s.done = true
+	return s.body.Close()
+}
+
+// Ollama API wire format.
+
+type ollamaChatRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Stream   bool            `json:"stream"`
+	Tools    []Tool          `json:"tools,omitempty"`
+	Format   any             `json:"format,omitempty"`
+	Options  *ollamaOptions  `json:"options,omitempty"`
+}
+
+type ollamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	Thinking  string           `json:"thinking,omitempty"`
+	Images    []string         `json:"images,omitempty"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+	ToolName  string           `json:"tool_name,omitempty"`
+}
+
+type ollamaToolCall struct {
+	ID       string             `json:"id,omitempty"`
+	Function ollamaFunctionCall `json:"function"`
+}
+
+type ollamaFunctionCall struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+type ollamaOptions struct {
+	Temperature      *float64 `json:"temperature,omitempty"`
+	TopP             *float64 `json:"top_p,omitempty"`
+	TopK             *int     `json:"top_k,omitempty"`
+	NumPredict       *int     `json:"num_predict,omitempty"`
+	Stop             []string `json:"stop,omitempty"`
+	Seed             *int     `json:"seed,omitempty"`
+	PresencePenalty  *float64 `json:"presence_penalty,omitempty"`
+	FrequencyPenalty *float64 `json:"frequency_penalty,omitempty"`
+}
+
+type ollamaChatResponse struct {
+	Model           string        `json:"model"`
+	CreatedAt       string        `json:"created_at"`
+	Message         ollamaMessage `json:"message"`
+	Done            bool          `json:"done"`
+	DoneReason      string        `json:"done_reason,omitempty"`
+	PromptEvalCount int           `json:"prompt_eval_count,omitempty"`
+	EvalCount       int           `json:"eval_count,omitempty"`
+	Error           string        `json:"error,omitempty"`
+}
+
+type ollamaEmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type ollamaEmbedResponse struct {
+	Model           string      `json:"model"`
+	Embeddings      [][]float64 `json:"embeddings"`
+	PromptEvalCount int         `json:"prompt_eval_count,omitempty"`
+}
+
+// toOllamaRequest converts req to the /api/chat format. Content parts are flattened to
+// text plus a raw base64 images array; image URLs must therefore be data URLs.
+func toOllamaRequest(req *ChatRequest) (*ollamaChatRequest, error) {
+	out := &ollamaChatRequest{
+		Model: req.Model,
+		Tools: req.Tools,
+	}
+	stop, err := stopSequences(req.Stop)
+	if err != nil {
+		return nil, err
+	}
+	opts := &ollamaOptions{
+		Temperature:      req.Temperature,
+		TopP:             req.TopP,
+		TopK:             req.TopK,
+		NumPredict:       req.MaxTokens,
+		Stop:             stop,
+		Seed:             req.Seed,
+		PresencePenalty:  req.PresencePenalty,
+		FrequencyPenalty: req.FrequencyPenalty,
+	}
+	if opts.Temperature != nil || opts.TopP != nil || opts.TopK != nil || opts.NumPredict != nil ||
+		opts.Stop != nil || opts.Seed != nil || opts.PresencePenalty != nil || opts.FrequencyPenalty != nil {
+		out.Options = opts
+	}
+	if rf := req.ResponseFormat; rf != nil {
+		switch rf.Type {
+		case "json_object":
+			out.Format = "json"
+		case "json_schema":
+			if rf.JSONSchema != nil && rf.JSONSchema.Schema != nil {
+				out.Format = rf.JSONSchema.Schema
+			} else {
+				out.Format = "json"
+			}
+		}
+	}
+
+	toolNames := make(map[string]string)
+	out.Messages = make([]ollamaMessage, 0, len(req.Messages))
+	for i, m := range req.Messages {
+		om, err := toOllamaMessage(fmt.Sprintf("messages[%d]", i), m)
+		if err != nil {
+			return nil, err
+		}
+		for _, tc := range m.ToolCalls {
+			toolNames[tc.ID] = tc.Function.Name
+		}
+		if m.Role == "tool" {
+			om.ToolName = m.Name
+			if om.ToolName == "" {
+				om.ToolName = toolNames[m.ToolCallID]
+			}
+		}
+		out.Messages = append(out.Messages, om)
+	}
+	return out, nil
+}
+
+func toOllamaMessage(field string, m Message) (ollamaMessage, error) {
+	out := ollamaMessage{Role: m.Role, Thinking: m.Reasoning}
+	parts, err := contentParts(m.Content)
+	if err != nil {
+		return out, &ValidationError{Field: field + ".content", Message: err.Error()}
+	}
+	var text strings.Builder
+	for j, p := range parts {
+		switch p.Type {
+		case "text":
+			text.WriteString(p.Text)
+		case "image_url":
+			if p.ImageURL == nil {
+				return out, &ValidationError{Field: fmt.Sprintf("%s.content[%d].image_url", field, j), Message: "cannot be nil"}
+			}
+			_, data, ok := parseDataURL(p.ImageURL.URL)
+			if !ok {
+				return out, &ValidationError{Field: fmt.Sprintf("%s.content[%d].image_url", field, j), Message: "ollama requires a base64 data URL"}
+			}
+			out.Images = append(out.Images, data)
+		default:
+			return out, &ValidationError{Field: fmt.Sprintf("%s.content[%d].type", field, j), Message: fmt.Sprintf("%q is not supported by ollama", p.Type)}
+		}
+	}
+	out.Content = text.String()
+	for _, tc := range m.ToolCalls {
+		out.ToolCalls = append(out.ToolCalls, ollamaToolCall{
+			Function: ollamaFunctionCall{Name: tc.Function.Name, Arguments: toolInput(tc.Function.Arguments)},
+		})
+	}
+	return out, nil
+}
+
+// toLLM converts an Ollama message. Ollama returns tool arguments as a JSON object and
+// usually omits call IDs, so IDs are derived from a running counter that also provides ToolCall.Index.
+func (m ollamaMessage) toLLM(numTools *int) *Message {
+	out := &Message{
+		Role:      m.Role,
+		Content:   m.Content,
+		Reasoning: m.Thinking,
+	}
+	for _, tc := range m.ToolCalls {
+		idx := *numTools
+		*numTools++
+		id := tc.ID
+		if id == "" {
+			id = fmt.Sprintf("call_%d", idx)
+		}
+		args := string(tc.Function.Arguments)
+		if args == "" || args == "null" {
+			args = "{}"
+		}
+		out.ToolCalls = append(out.ToolCalls, ToolCall{
+			Index:    &idx,
+			ID:       id,
+			Type:     "function",
+			Function: FunctionCall{Name: tc.Function.Name, Arguments: args},
+		})
+	}
+	return out
+}
+
+func (r *ollamaChatResponse) created() int64 {
+	t, err := time.Parse(time.RFC3339Nano, r.CreatedAt)
+	if err != nil {
+		return 0
+	}
+	return t.Unix()
+}
+
+func (r *ollamaChatResponse) usage() *Usage {
+	if !r.Done {
+		return nil
+	}
+	return &Usage{
+		PromptTokens:     r.PromptEvalCount,
+		CompletionTokens: r.EvalCount,
+		TotalTokens:      r.PromptEvalCount + r.EvalCount,
+	}
+}
+
+// ollamaFinishReason maps Ollama done reasons to OpenAI-style finish reasons.
+func ollamaFinishReason(reason string, hasToolCalls bool) string {
+	if hasToolCalls {
+		return "tool_calls"
+	}
+	switch reason {
+	case "", "stop":
+		return "stop"
+	case "length":
+		return "length"
+	default:
+		return reason
+	}
+}
@@ -0,0 +1,161 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTestOllamaClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	client, err := NewClient(ProviderOllama, WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return client
+}
+
+func TestToOllamaRequest(t *testing.T) {
+	maxTokens := 32
+	req := &ChatRequest{
+		Model: "llama3.2",
+		Messages: []Message{
+			{Role: "user", Content: []ContentPart{
+				{Type: "text", Text: "what is this?"},
+				{Type: "image_url", ImageURL: &ImageURL{URL: "data:image/jpeg;base64,QUJD"}},
+			}},
+			{Role: "assistant", ToolCalls: []ToolCall{{ID: "c1", Function: FunctionCall{Name: "lookup", Arguments: `{"q":"x"}`}}}},
+			{Role: "tool", ToolCallID: "c1", Content: "found"},
+		},
+		MaxTokens:      &maxTokens,
+		ResponseFormat: &ResponseFormat{Type: "json_object"},
+	}
+	got, err := toOllamaRequest(req)
+	if err != nil {
+		t.Fatalf("toOllamaRequest: %v", err)
+	}
+	if got.Messages[0].Content != "what is this?" || len(got.Messages[0].Images) != 1 || got.Messages[0].Images[0] != "QUJD" {
+		t.Errorf("user message = %+v", got.Messages[0])
+	}
+	if string(got.Messages[1].ToolCalls[0].Function.Arguments) != `{"q":"x"}` {
+		t.Errorf("tool call arguments = %s", got.Messages[1].ToolCalls[0].Function.Arguments)
+	}
+	if got.Messages[2].ToolName != "lookup" {
+		t.Errorf("tool_name = %q, want lookup", got.Messages[2].ToolName)
+	}
+	if got.Options == nil || got.Options.NumPredict == nil || *got.Options.NumPredict != 32 {
+		t.Errorf("Options = %+v", got.Options)
+	}
+	if got.Format != "json" {
+		t.Errorf("Format = %v", got.Format)
+	}
+
+	_, err = toOllamaRequest(&ChatRequest{Model: "m", Messages: []Message{{Role: "user", Content: []ContentPart{
+		{Type: "image_url", ImageURL: &ImageURL{URL: "https://example.com/a.png"}},
+	}}}})
+	if !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("remote image err = %v, want ErrInvalidRequest", err)
+	}
+}
+
+func TestOllamaChat_Create(t *testing.T) {
+	client := newTestOllamaClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("path = %q", r.URL.Path)
+		}
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["stream"] != false {
+			t.Errorf("stream = %v, want false", body["stream"])
+		}
+		io.WriteString(w, `{"model":"llama3.2","created_at":"2025-01-01T00:00:00Z","message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"lookup","arguments":{"q":"x"}}}]},"done":true,"done_reason":"stop","prompt_eval_count":5,"eval_count":2}`)
+	})
+	resp, err := client.Chat.Create(context.Background(), &ChatRequest{Model: "llama3.2", Messages: []Message{{Role: "user", Content: "hi"}}})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	msg := resp.Choices[0].Message
+	if len(msg.ToolCalls) != 1 || msg.ToolCalls[0].Function.Arguments != `{"q":"x"}` || msg.ToolCalls[0].ID == "" {
+		t.Errorf("ToolCalls = %+v", msg.ToolCalls)
+	}
+	if resp.Choices[0].FinishReason != "tool_calls" {
+		t.Errorf("FinishReason = %q", resp.Choices[0].FinishReason)
+	}
+	if resp.Usage == nil || resp.Usage.TotalTokens != 7 || resp.Created == 0 {
+		t.Errorf("resp = %+v", resp)
+	}
+}
+
+func TestOllamaChat_CreateStream(t *testing.T) {
+	client := newTestOllamaClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		io.WriteString(w, `{"model":"llama3.2","message":{"role":"assistant","content":"Hel"},"done":false}`+"\n")
+		io.WriteString(w, "\n")
+		io.WriteString(w, `{"model":"llama3.2","message":{"role":"assistant","content":"lo"},"done":false}`+"\n")
+		io.WriteString(w, `{"model":"llama3.2","message":{"role":"assistant","content":""},"done":true,"done_reason":"stop","prompt_eval_count":1,"eval_count":2}`+"\n")
+	})
+	stream, err := client.Chat.CreateStream(context.Background(), &ChatRequest{Model: "llama3.2", Messages: []Message{{Role: "user", Content: "hi"}}})
+	if err != nil {
+		t.Fatalf("CreateStream: %v", err)
+	}
+	defer stream.Close()
+	var text strings.Builder
+	var last *StreamChunk
+	for {
+		chunk, err := stream.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		text.WriteString(chunk.Choices[0].Delta.Content.(string))
+		last = chunk
+	}
+	if text.String() != "Hello" {
+		t.Errorf("text = %q", text.String())
+	}
+	if last.Choices[0].FinishReason != "stop" || last.Usage == nil || last.Usage.TotalTokens != 3 {
+		t.Errorf("last chunk = %+v", last)
+	}
+}
+
+func TestOllamaChat_StreamError(t *testing.T) {
+	client := newTestOllamaClient(t, func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"error":"model crashed"}`+"\n")
+	})
+	stream, err := client.Chat.CreateStream(context.Background(), &ChatRequest{Model: "llama3.2", Messages: []Message{{Role: "user", Content: "hi"}}})
+	if err != nil {
+		t.Fatalf("CreateStream: %v", err)
+	}
+	defer stream.Close()
+	if _, err := stream.Next(); err == nil || !strings.Contains(err.Error(), "model crashed") {
+		t.Errorf("Next err = %v", err)
+	}
+}
+
+func TestOllamaEmbedding_Create(t *testing.T) {
+	client := newTestOllamaClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/embed" {
+			t.Errorf("path = %q", r.URL.Path)
+		}
+		io.WriteString(w, `{"model":"nomic-embed-text","embeddings":[[0.1,0.2],[0.3,0.4]],"prompt_eval_count":4}`)
+	})
+	resp, err := client.Embeddings.Create(context.Background(), &EmbeddingRequest{Model: "nomic-embed-text", Input: []string{"a", "b"}})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if len(resp.Data) != 2 || resp.Data[1].Index != 1 || resp.Data[1].Embedding[0] != 0.3 {
+		t.Errorf("Data = %+v", resp.Data)
+	}
+	if resp.Usage == nil || resp.Usage.PromptTokens != 4 {
+		t.Errorf("Usage = %+v", resp.Usage)
+	}
+}
@@ -0,0 +1,42 @@
+package llm
+
+import "fmt"
+
+// Reasoning field names used by OpenAI-compatible servers for chain-of-thought text.
+const (
+	ReasoningFieldReasoningContent = "reasoning_content" // DeepSeek, vLLM, llama.cpp
+	ReasoningFieldReasoning        = "reasoning"         // Groq, OpenRouter-style servers
+)
+
+// CompatQuirks describes how an OpenAI-compatible server deviates from the OpenAI wire format.
+// The zero value assumes a server that behaves like OpenAI.
+type CompatQuirks struct {
+	// NoStreamUsage omits stream_options.include_usage, for servers that reject it
+	// or never report usage in streams.
+	NoStreamUsage bool
+	// MissingToolCallIndex infers ToolCall.Index on streamed tool-call deltas for servers
+	// that omit it: a delta with an ID starts a new call, one without continues the previous call.
+	MissingToolCallIndex bool
+	// ReasoningField selects the response field read into Message.Reasoning
+	// (ReasoningFieldReasoningContent or ReasoningFieldReasoning). When empty,
+	// reasoning_content is preferred and reasoning is used as a fallback.
+	ReasoningField string
+}
+
+// newOpenAICompatibleClient creates a client for servers speaking the OpenAI Chat Completions
+// and Embeddings wire format (vLLM, llama.cpp, Groq, Together, ...). WithBaseURL is required;
+// the API key is optional and sent as a bearer token when set.
+func newOpenAICompatibleClient(cfg *config) (*Client, error) {
+	if cfg.BaseURL == "" {
+		return nil, fmt.Errorf("%w: %s requires WithBaseURL()", ErrMissingBaseURL, ProviderOpenAICompatible)
+	}
+	auth := map[string]string{}
+	if cfg.APIKey != "" {
+		auth["Authorization"] = "Bearer " + cfg.APIKey
+	}
+	c := newCaller(ProviderOpenAICompatible, cfg, "", auth)
+	return &Client{
+		Chat:       &openAIChat{c: c, compatible: true, quirks: cfg.CompatQuirks},
+		Embeddings: &openAIEmbedding{c: c},
+	}, nil
+}
@@ -0,0 +1,100 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewClient_OpenAICompatibleRequiresBaseURL(t *testing.T) {
+	_, err := NewClient(ProviderOpenAICompatible)
+	if !errors.Is(err, ErrMissingBaseURL) {
+		t.Errorf("err = %v, want ErrMissingBaseURL", err)
+	}
+}
+
+func TestOpenAICompatibleChat_Create(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "" {
+			t.Errorf("Authorization = %q, want none without API key", auth)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		io.WriteString(w, `{"id":"c1","choices":[{"index":0,"message":{"role":"assistant","content":"4","reasoning":"2+2"},"finish_reason":"stop"}]}`)
+	}))
+	defer srv.Close()
+	client, err := NewClient(ProviderOpenAICompatible, WithBaseURL(srv.URL),
+		WithCompatQuirks(CompatQuirks{ReasoningField: ReasoningFieldReasoning}))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	maxTokens := 16
+	resp, err := client.Chat.Create(context.Background(), &ChatRequest{Model: "qwen", Messages: []Message{{Role: "user", Content: "2+2"}}, MaxTokens: &maxTokens})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if got["max_tokens"] != float64(16) {
+		t.Errorf("max_tokens = %v, want 16", got["max_tokens"])
+	}
+	if _, ok := got["max_completion_tokens"]; ok {
+		t.Error("max_completion_tokens should not be sent to compatible servers")
+	}
+	if resp.Choices[0].Message.Reasoning != "2+2" {
+		t.Errorf("Reasoning = %q", resp.Choices[0].Message.Reasoning)
+	}
+}
+
+func TestOpenAICompatibleChat_StreamQuirks(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if _, ok := body["stream_options"]; ok {
+			t.Error("stream_options should be omitted with NoStreamUsage")
+		}
+		io.WriteString(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"reasoning_content\":\"hmm\"}}]}\n\n")
+		io.WriteString(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"tool_calls\":[{\"id\":\"a\",\"type\":\"function\",\"function\":{\"name\":\"f\",\"arguments\":\"{\"}}]}}]}\n\n")
+		io.WriteString(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"tool_calls\":[{\"function\":{\"arguments\":\"}\"}}]}}]}\n\n")
+		io.WriteString(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"tool_calls\":[{\"id\":\"b\",\"type\":\"function\",\"function\":{\"name\":\"g\",\"arguments\":\"{}\"}}]}}]}\n\n")
+		io.WriteString(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+	client, err := NewClient(ProviderOpenAICompatible, WithBaseURL(srv.URL),
+		WithCompatQuirks(CompatQuirks{NoStreamUsage: true, MissingToolCallIndex: true}))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	stream, err := client.Chat.CreateStream(context.Background(), &ChatRequest{Model: "m", Messages: []Message{{Role: "user", Content: "hi"}}})
+	if err != nil {
+		t.Fatalf("CreateStream: %v", err)
+	}
+	defer stream.Close()
+
+	var reasoning string
+	var indexes []int
+	for {
+		chunk, err := stream.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		reasoning += chunk.Choices[0].Delta.Reasoning
+		for _, tc := range chunk.Choices[0].Delta.ToolCalls {
+			if tc.Index == nil {
+				t.Fatal("ToolCall.Index should be inferred")
+			}
+			indexes = append(indexes, *tc.Index)
+		}
+	}
+	if reasoning != "hmm" {
+		t.Errorf("reasoning = %q", reasoning)
+	}
+	if len(indexes) != 3 || indexes[0] != 0 || indexes[1] != 0 || indexes[2] != 1 {
+		t.Errorf("indexes = %v, want [0 0 1]", indexes)
+	}
+}
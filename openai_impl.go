@@ -0,0 +1,406 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// DefaultOpenAIBaseURL is the default base URL for the OpenAI API.
+const DefaultOpenAIBaseURL = "https://api.openai.com/v1"
+
+type openAIChat struct {
+	c *caller
+	// compatible selects the OpenAI-compatible dialect: max_tokens instead of
+	// max_completion_tokens, and the configured CompatQuirks.
+	compatible bool
+	quirks     CompatQuirks
+}
+
+type openAIEmbedding struct {
+	c *caller
+}
+
+func newOpenAIClient(cfg *config) (*Client, error) {
+	apiKey := cfg.APIKey
+	if apiKey == "" {
+		apiKey = os.Getenv("OPENAI_API_KEY")
+	}
+	if apiKey == "" {
+		return nil, fmt.Errorf("%w: set OPENAI_API_KEY or use WithAPIKey()", ErrMissingAPIKey)
+	}
+	c := newCaller(ProviderOpenAI, cfg, DefaultOpenAIBaseURL, map[string]string{
+		"Authorization": "Bearer " + apiKey,
+	})
+	return &Client{
+		Chat:       &openAIChat{c: c},
+		Embeddings: &openAIEmbedding{c: c},
+	}, nil
+}
+
+func (p *openAIChat) Create(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	if err := validateChatRequest(req); err != nil {
+		return nil, err
+	}
+	oaReq := p.toRequest(req)
+	var resp oaChatResponse
+	if err := p.c.doPost(ctx, "/chat/completions", oaReq, &resp); err != nil {
+		return nil, err
+	}
+	return resp.toLLMResponse(p.quirks.ReasoningField), nil
+}
+
+func (p *openAIChat) CreateStream(ctx context.Context, req *ChatRequest) (StreamReader, error) {
+	if err := validateChatRequest(req); err != nil {
+		return nil, err
+	}
+	oaReq := p.toRequest(req)
+	oaReq.Stream = true
+	if !p.quirks.NoStreamUsage {
+		oaReq.StreamOptions = &oaStreamOptions{IncludeUsage: true}
+	}
+	body, err := p.c.doStreamPost(ctx, "/chat/completions", oaReq)
+	if err != nil {
+		return nil, err
+	}
+	return &oaStreamReader{
+		provider: p.c.provider,
+		body:     body,
+		dec:      newSSEDecoder(body),
+		quirks:   p.quirks,
+		tools:    make(map[int]*oaToolIndex),
+	}, nil
+}
+
+func (p *openAIChat) toRequest(req *ChatRequest) *oaChatRequest {
+	oaReq := toOAChatRequest(req)
+	if p.compatible {
+		oaReq.MaxTokens, oaReq.MaxCompletionTokens = oaReq.MaxCompletionTokens, nil
+	}
+	return oaReq
+}
+
+func (p *openAIEmbedding) Create(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error) {
+	if err := validateEmbeddingRequest(req); err != nil {
+		return nil, err
+	}
+	oaReq := &oaEmbeddingRequest{Model: req.Model, Input: req.Input}
+	var resp EmbeddingResponse
+	if err := p.c.doPost(ctx, "/embeddings", oaReq, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// oaStreamReader reads an OpenAI Chat Completions SSE stream.
+type oaStreamReader struct {
+	provider Provider
+	body     io.ReadCloser
+	dec      *sseDecoder
+	done     bool
+	quirks   CompatQuirks
+	tools    map[int]*oaToolIndex // per choice index, for CompatQuirks.MissingToolCallIndex
+}
+
+// oaToolIndex tracks tool-call positions within one choice of a stream.
+type oaToolIndex struct {
+	next, last int
+}
+
+func (s *oaStreamReader) Next() (*StreamChunk, error) {
+	if s.done {
+		return nil, io.EOF
+	}
+	for {
+		ev, err := s.dec.Next()
+		if err != nil {
+			if err == io.EOF {
+				s.done = true
+			}
+			return nil, err
+		}
+		if bytes.Equal(bytes.TrimSpace(ev.Data), []byte("[DONE]")) {
+			s.done = true
+			return nil, io.EOF
+		}
+		var chunk oaChatResponse
+		if err := json.Unmarshal(ev.Data, &chunk); err != nil {
+			return nil, fmt.Errorf("llm: %s: malformed stream chunk: %w", s.provider, err)
+		}
+		if chunk.Error != nil {
+			return nil, &APIError{Provider: s.provider, Code: errorCode(chunk.Error.Code, chunk.Error.Type), Message: chunk.Error.Message}
+		}
+		if len(chunk.Choices) == 0 && chunk.Usage == nil {
+			continue
+		}
+		out := chunk.toLLMChunk(s.quirks.ReasoningField)
+		if s.quirks.MissingToolCallIndex {
+			s.fillToolCallIndex(out)
+		}
+		return out, nil
+	}
+}
+
+// fillToolCallIndex assigns ToolCall.Index to deltas from servers that omit it:
+// a delta with an ID starts a new call, a delta without one continues the previous call.
+func (s *oaStreamReader) fillToolCallIndex(chunk *StreamChunk) {
+	for _, ch := range chunk.Choices {
+		if ch.Delta == nil {
+			continue
+		}
+		st := s.tools[ch.Index]
+		if st == nil {
+			st = &oaToolIndex{last: -1}
+			s.tools[ch.Index] = st
+		}
+		for i := range ch.Delta.ToolCalls {
+			tc := &ch.Delta.ToolCalls[i]
+			if tc.Index != nil {
+				st.last = *tc.Index
+				if *tc.Index >= st.next {
+					st.next = *tc.Index + 1
+				}
+				continue
+			}
+			if tc.ID != "" || st.last < 0 {
+				st.last = st.next
+				st.next++
+			}
+			idx := st.last
+			tc.Index = &idx
+		}
+	}
+}
+
+func (s *oaStreamReader) Close() error {
+	s.done = true
+	return s.body.Close()
+}
+
+// OpenAI Chat Completions wire format.
+
+type oaChatRequest struct {
+	Model               string           `json:"model"`
+	Messages            []oaMessage      `json:"messages"`
+	Temperature         *float64         `json:"temperature,omitempty"`
+	TopP                *float64         `json:"top_p,omitempty"`
+	MaxTokens           *int             `json:"max_tokens,omitempty"`
+	MaxCompletionTokens *int             `json:"max_completion_tokens,omitempty"`
+	Stop                any              `json:"stop,omitempty"`
+	Stream              bool             `json:"stream,omitempty"`
+	StreamOptions       *oaStreamOptions `json:"stream_options,omitempty"`
+	Seed                *int             `json:"seed,omitempty"`
+	PresencePenalty     *float64         `json:"presence_penalty,omitempty"`
+	FrequencyPenalty    *float64         `json:"frequency_penalty,omitempty"`
+	ResponseFormat      *ResponseFormat  `json:"response_format,omitempty"`
+	Tools               []Tool           `json:"tools,omitempty"`
+	ToolChoice          any              `json:"tool_choice,omitempty"`
+	ParallelToolCalls   *bool            `json:"parallel_tool_calls,omitempty"`
+	User                string           `json:"user,omitempty"`
+}
+
+type oaStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type oaMessage struct {
+	Role             string       `json:"role,omitempty"`
+	Content          any          `json:"content"`
+	Name             string       `json:"name,omitempty"`
+	ToolCallID       string       `json:"tool_call_id,omitempty"`
+	ToolCalls        []oaToolCall `json:"tool_calls,omitempty"`
+	Reasoning        string       `json:"reasoning,omitempty"`
+	ReasoningContent string       `json:"reasoning_content,omitempty"`
+}
+
+type oaToolCall struct {
+	Index    *int           `json:"index,omitempty"`
+	ID       string         `json:"id,omitempty"`
+	Type     string         `json:"type,omitempty"`
+	Function oaFunctionCall `json:"function"`
+}
+
+type oaFunctionCall struct {
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments"`
+}
+
+type oaChatResponse struct {
+	ID      string     `json:"id"`
+	Object  string     `json:"object"`
+	Created int64      `json:"created"`
+	Model   string     `json:"model"`
+	Choices []oaChoice `json:"choices"`
+	Usage   *oaUsage   `json:"usage,omitempty"`
+	Error   *oaError   `json:"error,omitempty"`
+}
+
+type oaChoice struct {
+	Index        int        `json:"index"`
+	Message      *oaMessage `json:"message,omitempty"`
+	Delta        *oaMessage `json:"delta,omitempty"`
+	FinishReason string     `json:"finish_reason,omitempty"`
+}
+
+type oaUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+type oaError struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	Code    any    `json:"code"`
+}
+
+type oaEmbeddingRequest struct {
+	Model string `json:"model"`
+	Input any    `json:"input"`
+}
+
+func toOAChatRequest(req *ChatRequest) *oaChatRequest {
+	if req == nil {
+		return nil
+	}
+	oaReq := &oaChatRequest{
+		Model:               req.Model,
+		Temperature:         req.Temperature,
+		TopP:                req.TopP,
+		MaxCompletionTokens: req.MaxTokens,
+		Stop:                req.Stop,
+		Seed:                req.Seed,
+		PresencePenalty:     req.PresencePenalty,
+		FrequencyPenalty:    req.FrequencyPenalty,
+		ResponseFormat:      req.ResponseFormat,
+		Tools:               req.Tools,
+		ToolChoice:          req.ToolChoice,
+		ParallelToolCalls:   req.ParallelToolCalls,
+		User:                req.User,
+	}
+	oaReq.Messages = make([]oaMessage, len(req.Messages))
+	for i, m := range req.Messages {
+		oaReq.Messages[i] = toOAMessage(m)
+	}
+	return oaReq
+}
+
+func toOAMessage(m Message) oaMessage {
+	out := oaMessage{
+		Role:       m.Role,
+		Content:    m.Content,
+		Name:       m.Name,
+		ToolCallID: m.ToolCallID,
+	}
+	if len(m.ToolCalls) > 0 {
+		out.ToolCalls = make([]oaToolCall, len(m.ToolCalls))
+		for i, tc := range m.ToolCalls {
+			typ := tc.Type
+			if typ == "" {
+				typ = "function"
+			}
+			out.ToolCalls[i] = oaToolCall{
+				ID:   tc.ID,
+				Type: typ,
+				Function: oaFunctionCall{
+					Name:      tc.Function.Name,
+					Arguments: tc.Function.Arguments,
+				},
+			}
+		}
+	}
+	return out
+}
+
+func (r *oaChatResponse) toLLMResponse(reasoningField string) *ChatResponse {
+	out := &ChatResponse{
+		ID:      r.ID,
+		Object:  r.Object,
+		Created: r.Created,
+		Model:   r.Model,
+		Choices: make([]Choice, len(r.Choices)),
+		Usage:   r.Usage.toLLM(),
+	}
+	for i, ch := range r.Choices {
+		out.Choices[i] = ch.toLLM(reasoningField)
+	}
+	return out
+}
+
+func (r *oaChatResponse) toLLMChunk(reasoningField string) *StreamChunk {
+	out := &StreamChunk{
+		ID:      r.ID,
+		Object:  r.Object,
+		Created: r.Created,
+		Model:   r.Model,
+		Choices: make([]Choice, len(r.Choices)),
+		Usage:   r.Usage.toLLM(),
+	}
+	for i, ch := range r.Choices {
+		out.Choices[i] = ch.toLLM(reasoningField)
+	}
+	return out
+}
+
+func (ch oaChoice) toLLM(reasoningField string) Choice {
+	return Choice{
+		Index:        ch.Index,
+		Message:      ch.Message.toLLM(reasoningField),
+		Delta:        ch.Delta.toLLM(reasoningField),
+		FinishReason: ch.FinishReason,
+	}
+}
+
+// toLLM converts a response message. reasoningField selects which field carries
+// reasoning text; when empty, reasoning_content is preferred over reasoning.
+func (m *oaMessage) toLLM(reasoningField string) *Message {
+	if m == nil {
+		return nil
+	}
+	out := &Message{
+		Role:       m.Role,
+		Content:    m.Content,
+		Name:       m.Name,
+		ToolCallID: m.ToolCallID,
+	}
+	switch reasoningField {
+	case ReasoningFieldReasoning:
+		out.Reasoning = m.Reasoning
+	case ReasoningFieldReasoningContent:
+		out.Reasoning = m.ReasoningContent
+	default:
+		out.Reasoning = m.ReasoningContent
+		if out.Reasoning == "" {
+			out.Reasoning = m.Reasoning
+		}
+	}
+	if len(m.ToolCalls) > 0 {
+		out.ToolCalls = make([]ToolCall, len(m.ToolCalls))
+		for i, tc := range m.ToolCalls {
+			out.ToolCalls[i] = ToolCall{
+				Index: tc.Index,
+				ID:    tc.ID,
+				Type:  tc.Type,
+				Function: FunctionCall{
+					Name:      tc.Function.Name,
+					Arguments: tc.Function.Arguments,
+				},
+			}
+		}
+	}
+	return out
+}
+
+func (u *oaUsage) toLLM() *Usage {
+	if u == nil {
+		return nil
+	}
+	return &Usage{
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+		TotalTokens:      u.TotalTokens,
+	}
+}
@@ -0,0 +1,193 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func newTestOpenAIClient(t *testing.T, handler http.HandlerFunc, opts ...Option) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	opts = append([]Option{WithAPIKey("sk-test"), WithBaseURL(srv.URL)}, opts...)
+	client, err := NewClient(ProviderOpenAI, opts...)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return client
+}
+
+func TestOpenAIChat_Create(t *testing.T) {
+	var got map[string]any
+	client := newTestOpenAIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" {
+			t.Errorf("path = %q, want /chat/completions", r.URL.Path)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Bearer sk-test" {
+			t.Errorf("Authorization = %q", auth)
+		}
+		if h := r.Header.Get("X-Custom"); h != "1" {
+			t.Errorf("X-Custom = %q, want 1", h)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		io.WriteString(w, `{"id":"c1","object":"chat.completion","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":null,"tool_calls":[{"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Paris\"}"}}]},"finish_reason":"tool_calls"}],"usage":{"prompt_tokens":7,"completion_tokens":3,"total_tokens":10}}`)
+	}, WithHeaders(map[string]string{"X-Custom": "1"}))
+
+	maxTokens, topK := 64, 5
+	resp, err := client.Chat.Create(context.Background(), &ChatRequest{
+		Model:     "gpt-4o",
+		Messages:  []Message{{Role: "user", Content: "weather?", Reasoning: "ignored"}},
+		MaxTokens: &maxTokens,
+		TopK:      &topK,
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if got["max_completion_tokens"] != float64(64) {
+		t.Errorf("max_completion_tokens = %v, want 64", got["max_completion_tokens"])
+	}
+	if _, ok := got["top_k"]; ok {
+		t.Error("top_k should not be sent to OpenAI")
+	}
+	if _, ok := got["stream"]; ok {
+		t.Error("stream should be omitted for Create")
+	}
+	if resp.Usage == nil || resp.Usage.TotalTokens != 10 {
+		t.Errorf("Usage = %+v", resp.Usage)
+	}
+	msg := resp.Choices[0].Message
+	if msg == nil || len(msg.ToolCalls) != 1 || msg.ToolCalls[0].Function.Name != "get_weather" {
+		t.Fatalf("Message = %+v", msg)
+	}
+	if resp.Choices[0].FinishReason != "tool_calls" {
+		t.Errorf("FinishReason = %q", resp.Choices[0].FinishReason)
+	}
+}
+
+func TestOpenAIChat_CreateStream(t *testing.T) {
+	client := newTestOpenAIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["stream"] != true {
+			t.Errorf("stream = %v, want true", body["stream"])
+		}
+		if opts, _ := body["stream_options"].(map[string]any); opts["include_usage"] != true {
+			t.Errorf("stream_options = %v", body["stream_options"])
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, ": keep-alive\n\n")
+		io.WriteString(w, "data: {\"id\":\"c1\",\"choices\":[{\"index\":0,\"delta\":{\"role\":\"assistant\",\"content\":\"Hel\"}}]}\n\n")
+		io.WriteString(w, "data: {\"id\":\"c1\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"lo\"},\"finish_reason\":\"stop\"}]}\n\n")
+		io.WriteString(w, "data: {\"id\":\"c1\",\"choices\":[],\"usage\":{\"prompt_tokens\":1,\"completion_tokens\":2,\"total_tokens\":3}}\n\n")
+		io.WriteString(w, "data: [DONE]\n\n")
+	})
+
+	stream, err := client.Chat.CreateStream(context.Background(), &ChatRequest{
+		Model:    "gpt-4o",
+		Messages: []Message{{Role: "user", Content: "hi"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateStream: %v", err)
+	}
+	defer stream.Close()
+
+	var text strings.Builder
+	var usage *Usage
+	for {
+		chunk, err := stream.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+		for _, ch := range chunk.Choices {
+			if s, ok := ch.Delta.Content.(string); ok {
+				text.WriteString(s)
+			}
+		}
+	}
+	if text.String() != "Hello" {
+		t.Errorf("text = %q, want Hello", text.String())
+	}
+	if usage == nil || usage.TotalTokens != 3 {
+		t.Errorf("usage = %+v", usage)
+	}
+}
+
+func TestOpenAIEmbedding_Create(t *testing.T) {
+	client := newTestOpenAIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/embeddings" {
+			t.Errorf("path = %q, want /embeddings", r.URL.Path)
+		}
+		io.WriteString(w, `{"object":"list","data":[{"object":"embedding","embedding":[0.1,0.2],"index":0}],"usage":{"prompt_tokens":2,"total_tokens":2}}`)
+	})
+	resp, err := client.Embeddings.Create(context.Background(), &EmbeddingRequest{Model: "text-embedding-3-small", Input: "hi"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if len(resp.Data) != 1 || len(resp.Data[0].Embedding) != 2 {
+		t.Errorf("Data = %+v", resp.Data)
+	}
+	if resp.Usage == nil || resp.Usage.TotalTokens != 2 {
+		t.Errorf("Usage = %+v", resp.Usage)
+	}
+}
+
+func TestOpenAIChat_Retries(t *testing.T) {
+	var calls atomic.Int32
+	client := newTestOpenAIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("retry-after-ms", "1")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			io.WriteString(w, `{"error":{"message":"overloaded"}}`)
+			return
+		}
+		io.WriteString(w, `{"id":"c1","choices":[{"index":0,"message":{"role":"assistant","content":"ok"}}]}`)
+	}, WithMaxRetries(1))
+
+	resp, err := client.Chat.Create(context.Background(), &ChatRequest{Model: "m", Messages: []Message{{Role: "user", Content: "hi"}}})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("calls = %d, want 2", calls.Load())
+	}
+	if resp.Choices[0].Message.Content != "ok" {
+		t.Errorf("Content = %v", resp.Choices[0].Message.Content)
+	}
+}
+
+func TestOpenAIChat_NonRetryableError(t *testing.T) {
+	var calls atomic.Int32
+	client := newTestOpenAIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, `{"error":{"message":"bad model"}}`)
+	})
+	_, err := client.Chat.Create(context.Background(), &ChatRequest{Model: "m", Messages: []Message{{Role: "user", Content: "hi"}}})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("calls = %d, want 1", calls.Load())
+	}
+}
+
+func TestNewClient_OpenAIMissingAPIKey(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "")
+	_, err := NewClient(ProviderOpenAI)
+	if !errors.Is(err, ErrMissingAPIKey) {
+		t.Errorf("err = %v, want ErrMissingAPIKey", err)
+	}
+}
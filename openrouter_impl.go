@@ -2,11 +2,14 @@ package llm
 
 import (
 	"context"
+	"errors"
 	"io"
+	"net/http"
 
 	"github.com/MetaDiv-AI/openrouter"
 	"github.com/MetaDiv-AI/openrouter/chat"
 	"github.com/MetaDiv-AI/openrouter/embeddings"
+	oerrors "github.com/MetaDiv-AI/openrouter/errors"
 )
 
 type openRouterChat struct {
@@ -62,46 +65,6 @@ func buildOpenRouterOptions(cfg *config) []openrouter.Option {
 	return opts
 }
 
-func validateChatRequest(req *ChatRequest) error {
-	if req == nil {
-		return &ValidationError{Field: "request", Message: "cannot be nil"}
-	}
-	if req.Model == "" {
-		return &ValidationError{Field: "model", Message: "cannot be empty"}
-	}
-	if len(req.Messages) == 0 {
-		return &ValidationError{Field: "messages", Message: "cannot be empty"}
-	}
-	return nil
-}
-
-func validateEmbeddingRequest(req *EmbeddingRequest) error {
-	if req == nil {
-		return &ValidationError{Field: "request", Message: "cannot be nil"}
-	}
-	if req.Model == "" {
-		return &ValidationError{Field: "model", Message: "cannot be empty"}
-	}
-	if req.Input == nil {
-		return &ValidationError{Field: "input", Message: "cannot be nil"}
-	}
-	switch v := req.Input.(type) {
-	case string:
-		if v == "" {
-			return &ValidationError{Field: "input", Message: "cannot be empty string"}
-		}
-	case []string:
-		if len(v) == 0 {
-			return &ValidationError{Field: "input", Message: "cannot be empty slice"}
-		}
-	case []interface{}:
-		if len(v) == 0 {
-			return &ValidationError{Field: "input", Message: "cannot be empty slice"}
-		}
-	}
-	return nil
-}
-
 func (c *openRouterChat) Create(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
 	if err := validateChatRequest(req); err != nil {
 		return nil, err
@@ -109,7 +72,7 @@ func (c *openRouterChat) Create(ctx context.Context, req *ChatRequest) (*ChatRes
 	orReq := toORChatRequest(req)
 	resp, err := c.or.Chat.Create(ctx, orReq)
 	if err != nil {
-		return nil, err
+		return nil, toORAPIError(err)
 	}
 	return toLLMChatResponse(resp), nil
 }
@@ -121,7 +84,7 @@ func (c *openRouterChat) CreateStream(ctx context.Context, req *ChatRequest) (St
 	orReq := toORChatRequest(req)
 	orStream, err := c.or.Chat.CreateStream(ctx, orReq)
 	if err != nil {
-		return nil, err
+		return nil, toORAPIError(err)
 	}
 	return &orStreamReader{inner: orStream}, nil
 }
@@ -133,11 +96,32 @@ func (c *openRouterEmbedding) Create(ctx context.Context, req *EmbeddingRequest)
 	embReq := &embeddings.CreateRequest{Model: req.Model, Input: req.Input}
 	embResp, err := c.or.Embeddings.Create(ctx, embReq)
 	if err != nil {
-		return nil, err
+		return nil, toORAPIError(err)
 	}
 	return toLLMEmbeddingResponse(embResp), nil
 }
 
+// toORAPIError maps an OpenRouterError to an APIError, keeping the original as Err.
+// OpenRouter reports moderation rejections as 403 and exhausted credits as 402.
+func toORAPIError(err error) error {
+	var oe *oerrors.OpenRouterError
+	if !errors.As(err, &oe) {
+		return err
+	}
+	status := oe.Code
+	if status == 0 {
+		status = oe.HTTPStatus
+	}
+	e := &APIError{Provider: ProviderOpenRouter, StatusCode: status, Message: oe.Message, Err: err}
+	if status == http.StatusForbidden {
+		e.Code = "moderation"
+	}
+	if raw, ok := oe.Metadata["raw"].(string); ok {
+		e.Body = raw
+	}
+	return e
+}
+
 type orStreamReader struct {
 	inner *chat.StreamReader
 }
@@ -151,7 +135,7 @@ func (s *orStreamReader) Next() (*StreamChunk, error) {
 		return nil, io.EOF
 	}
 	if err != nil {
-		return nil, err
+		return nil, toORAPIError(err)
 	}
 	return toLLMStreamChunk(chunk), nil
 }
@@ -10,7 +10,12 @@ import (
 type Provider string
 
 const (
-	ProviderOpenRouter Provider = "openrouter"
+	ProviderOpenRouter       Provider = "openrouter"
+	ProviderOpenAI           Provider = "openai"
+	ProviderAnthropic        Provider = "anthropic"
+	ProviderGemini           Provider = "gemini"
+	ProviderOllama           Provider = "ollama"
+	ProviderOpenAICompatible Provider = "openai-compatible" // requires WithBaseURL
 )
 
 // Client exposes Chat and Embeddings providers.
@@ -33,6 +38,16 @@ type config struct {
 	Referer     string
 	Title       string
 	ForwardedFor string
+	CompatQuirks CompatQuirks
+	RetryPolicy  RetryPolicy
+	StrictStream bool
+	StreamTimeouts *StreamTimeouts
+	// streamTimeoutsApplied is set by factories that apply StreamTimeouts themselves,
+	// below their retry layer.
+	streamTimeoutsApplied bool
+
+	ChatMiddleware      []ChatMiddleware
+	EmbeddingMiddleware []EmbeddingMiddleware
 }
 
 // WithAPIKey sets the API key.
@@ -56,13 +71,21 @@ func WithTimeout(timeout time.Duration) Option {
 	}
 }
 
-// WithMaxRetries sets the maximum number of retries.
+// WithMaxRetries sets the maximum number of retries for retryable errors.
+// Built-in providers retry with exponential backoff and full jitter, honoring Retry-After.
 func WithMaxRetries(n int) Option {
 	return func(c *config) {
 		c.MaxRetries = n
 	}
 }
 
+// WithRetryPolicy sets the policy deciding which errors built-in providers retry.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *config) {
+		c.RetryPolicy = policy
+	}
+}
+
 // WithHeaders sets custom headers.
 func WithHeaders(headers map[string]string) Option {
 	return func(c *config) {
@@ -96,6 +119,13 @@ func WithForwardedFor(ip string) Option {
 	}
 }
 
+// WithCompatQuirks configures wire-format deviations for ProviderOpenAICompatible.
+func WithCompatQuirks(q CompatQuirks) Option {
+	return func(c *config) {
+		c.CompatQuirks = q
+	}
+}
+
 // WithDebug enables debug logging.
 func WithDebug(debug bool) Option {
 	return func(c *config) {
@@ -116,21 +146,42 @@ const DefaultTimeout = 60 * time.Second
 // DefaultMaxRetries is the default number of retries for retryable errors (3).
 const DefaultMaxRetries = 3
 
-// NewClient creates a new client for the given provider with the specified options.
-func NewClient(provider Provider, opts ...Option) (*Client, error) {
-	cfg := &config{
+// newConfig returns the configuration NewClient starts from before applying options.
+func newConfig() *config {
+	return &config{
 		Headers:    make(map[string]string),
 		Timeout:    DefaultTimeout,
 		MaxRetries: DefaultMaxRetries,
 	}
+}
+
+// NewClient creates a new client for the given provider with the specified options.
+// Middleware configured with WithChatMiddleware and WithEmbeddingMiddleware wraps the provider's Chat and Embeddings.
+func NewClient(provider Provider, opts ...Option) (*Client, error) {
+	cfg := newConfig()
 	for _, opt := range opts {
 		opt(cfg)
 	}
 
-	switch provider {
-	case ProviderOpenRouter:
-		return newOpenRouterClient(cfg)
-	default:
+	factory, ok := lookupProvider(provider)
+	if !ok {
 		return nil, &ErrUnknownProvider{Provider: string(provider)}
 	}
+	client, err := factory(Config{c: cfg})
+	if err != nil || client == nil {
+		return client, err
+	}
+	if client.Chat != nil {
+		if cfg.StreamTimeouts != nil && !cfg.streamTimeoutsApplied {
+			client.Chat = timeoutChat{client.Chat, *cfg.StreamTimeouts}
+		}
+		if cfg.StrictStream {
+			client.Chat = strictChat{client.Chat}
+		}
+		client.Chat = ChainChat(client.Chat, cfg.ChatMiddleware...)
+	}
+	if client.Embeddings != nil {
+		client.Embeddings = ChainEmbedding(client.Embeddings, cfg.EmbeddingMiddleware...)
+	}
+	return client, nil
 }
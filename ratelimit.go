@@ -0,0 +1,343 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// RateLimitConfig configures the client-side rate limiters. Zero limits are unlimited.
+type RateLimitConfig struct {
+	// RequestsPerMinute caps requests per key.
+	RequestsPerMinute int
+	// TokensPerMinute caps estimated tokens per key. The estimate is reconciled with
+	// the reported Usage once the response arrives.
+	TokensPerMinute int
+	// PerUser keys chat limits by ChatRequest.User in addition to the model.
+	PerUser bool
+	// Key, if set, replaces the per-model (and per-user) key: every request draws from
+	// the same buckets.
+	Key string
+	// FailFast returns ErrRateLimitExceeded instead of waiting for capacity.
+	FailFast bool
+	// EstimateTokens estimates the token cost of a chat request (default EstimateChatTokens).
+	EstimateTokens func(*ChatRequest) int
+}
+
+// ErrRateLimitExceeded is returned by a FailFast rate limiter when Key has no capacity left.
+type ErrRateLimitExceeded struct {
+	Key string
+	// Limit is "requests" or "tokens".
+	Limit string
+	// RetryAfter is the time until enough capacity is available.
+	RetryAfter time.Duration
+}
+
+func (e *ErrRateLimitExceeded) Error() string {
+	return fmt.Sprintf("llm: client %s-per-minute limit exceeded for %q (retry in %s)", e.Limit, e.Key, e.RetryAfter.Round(time.Millisecond))
+}
+
+// Is supports errors.Is for ErrRateLimitExceeded.
+func (e *ErrRateLimitExceeded) Is(target error) bool {
+	t, ok := target.(*ErrRateLimitExceeded)
+	return ok && (t == nil || ((t.Key == "" || t.Key == e.Key) && (t.Limit == "" || t.Limit == e.Limit)))
+}
+
+// charsPerToken approximates tokenizer density for English text and code.
+const charsPerToken = 4
+
+// estimateTextTokens approximates the token count of s.
+func estimateTextTokens(s string) int {
+	return (len(s) + charsPerToken - 1) / charsPerToken
+}
+
+// EstimateChatTokens approximates the tokens a chat request consumes: roughly four characters
+// per token of message text and tool calls, a small per-message overhead, plus MaxTokens.
+func EstimateChatTokens(req *ChatRequest) int {
+	if req == nil {
+		return 0
+	}
+	n := 0
+	for _, m := range req.Messages {
+		n += 4 + estimateTextTokens(contentText(m.Content))
+		for _, tc := range m.ToolCalls {
+			n += estimateTextTokens(tc.Function.Name) + estimateTextTokens(tc.Function.Arguments)
+		}
+	}
+	if req.MaxTokens != nil {
+		n += *req.MaxTokens
+	}
+	return n
+}
+
+// tokenBucket refills continuously at rate per second up to capacity.
+type tokenBucket struct {
+	capacity float64
+	level    float64
+	rate     float64
+	last     time.Time
+}
+
+func newTokenBucket(perMinute int, now time.Time) *tokenBucket {
+	if perMinute <= 0 {
+		return nil
+	}
+	c := float64(perMinute)
+	return &tokenBucket{capacity: c, level: c, rate: c / 60, last: now}
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	b.level = min(b.capacity, b.level+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+}
+
+// wait returns how long until n units are available. Requests larger than the bucket
+// only wait for a full bucket.
+func (b *tokenBucket) wait(n float64) time.Duration {
+	n = min(n, b.capacity)
+	if b.level >= n {
+		return 0
+	}
+	return time.Duration((n - b.level) / b.rate * float64(time.Second))
+}
+
+// rateLimitSweepInterval is how often acquire drops idle buckets.
+const rateLimitSweepInterval = time.Minute
+
+// rateLimiter holds the request and token buckets for every key. Buckets that have
+// refilled to capacity behave like new ones, so they are dropped periodically to keep
+// per-user keys from accumulating.
+type rateLimiter struct {
+	cfg RateLimitConfig
+	now func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string][2]*tokenBucket
+	swept   time.Time
+}
+
+func newRateLimiter(cfg RateLimitConfig) *rateLimiter {
+	if cfg.EstimateTokens == nil {
+		cfg.EstimateTokens = EstimateChatTokens
+	}
+	return &rateLimiter{cfg: cfg, now: time.Now, buckets: make(map[string][2]*tokenBucket)}
+}
+
+// acquire takes one request and tokens from key's buckets, waiting for capacity unless FailFast.
+// It returns the number of tokens actually taken.
+func (l *rateLimiter) acquire(ctx context.Context, key string, tokens int) (int, error) {
+	for {
+		l.mu.Lock()
+		now := l.now()
+		l.sweep(now)
+		b, ok := l.buckets[key]
+		if !ok {
+			b = [2]*tokenBucket{newTokenBucket(l.cfg.RequestsPerMinute, now), newTokenBucket(l.cfg.TokensPerMinute, now)}
+			l.buckets[key] = b
+		}
+		reqs, toks := b[0], b[1]
+		var delay time.Duration
+		limit := "requests"
+		if reqs != nil {
+			reqs.refill(now)
+			delay = reqs.wait(1)
+		}
+		if toks != nil {
+			toks.refill(now)
+			if d := toks.wait(float64(tokens)); d > delay {
+				delay, limit = d, "tokens"
+			}
+		}
+		if delay == 0 {
+			if reqs != nil {
+				reqs.level--
+			}
+			taken := 0
+			if toks != nil {
+				taken = int(min(float64(tokens), toks.capacity))
+				toks.level -= float64(taken)
+			}
+			l.mu.Unlock()
+			return taken, nil
+		}
+		l.mu.Unlock()
+
+		if l.cfg.FailFast {
+			return 0, &ErrRateLimitExceeded{Key: key, Limit: limit, RetryAfter: delay}
+		}
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return 0, ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// sweep drops the buckets of keys that are full again, at most once per
+// rateLimitSweepInterval. Caller holds mu.
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < rateLimitSweepInterval {
+		return
+	}
+	l.swept = now
+	for key, b := range l.buckets {
+		idle := true
+		for _, bucket := range b {
+			if bucket != nil {
+				bucket.refill(now)
+				idle = idle && bucket.level >= bucket.capacity
+			}
+		}
+		if idle {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// reconcile adjusts key's token bucket by the difference between actual and reserved usage.
+// Overruns leave the bucket in debt, delaying later requests; an actual of 0 returns the
+// whole reservation, as for failed calls.
+func (l *rateLimiter) reconcile(key string, reserved, actual int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if toks := l.buckets[key][1]; toks != nil {
+		toks.refill(l.now())
+		toks.level = min(toks.capacity, toks.level-float64(actual-reserved))
+	}
+}
+
+// NewRateLimitChatProvider wraps p with request and token buckets keyed by ChatRequest.Model
+// (and ChatRequest.User when PerUser is set), or by cfg.Key when set.
+func NewRateLimitChatProvider(p ChatProvider, cfg RateLimitConfig) ChatProvider {
+	return &rateLimitChat{next: p, l: newRateLimiter(cfg)}
+}
+
+// NewRateLimitEmbeddingProvider wraps p with request and token buckets keyed by EmbeddingRequest.Model,
+// or by cfg.Key when set.
+// Tokens are estimated from the input text.
+func NewRateLimitEmbeddingProvider(p EmbeddingProvider, cfg RateLimitConfig) EmbeddingProvider {
+	return &rateLimitEmbedding{next: p, l: newRateLimiter(cfg)}
+}
+
+// RateLimitChatMiddleware returns a ChatMiddleware that applies NewRateLimitChatProvider.
+func RateLimitChatMiddleware(cfg RateLimitConfig) ChatMiddleware {
+	return func(p ChatProvider) ChatProvider { return NewRateLimitChatProvider(p, cfg) }
+}
+
+// RateLimitEmbeddingMiddleware returns an EmbeddingMiddleware that applies NewRateLimitEmbeddingProvider.
+func RateLimitEmbeddingMiddleware(cfg RateLimitConfig) EmbeddingMiddleware {
+	return func(p EmbeddingProvider) EmbeddingProvider { return NewRateLimitEmbeddingProvider(p, cfg) }
+}
+
+type rateLimitChat struct {
+	next ChatProvider
+	l    *rateLimiter
+}
+
+func (c *rateLimitChat) key(req *ChatRequest) string {
+	if c.l.cfg.Key != "" || req == nil {
+		return c.l.cfg.Key
+	}
+	if c.l.cfg.PerUser && req.User != "" {
+		return req.Model + "|" + req.User
+	}
+	return req.Model
+}
+
+func (c *rateLimitChat) Create(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	key := c.key(req)
+	reserved, err := c.l.acquire(ctx, key, c.l.cfg.EstimateTokens(req))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.next.Create(ctx, req)
+	if err != nil {
+		c.l.reconcile(key, reserved, 0)
+	} else if resp.Usage != nil && resp.Usage.TotalTokens > 0 {
+		c.l.reconcile(key, reserved, resp.Usage.TotalTokens)
+	}
+	return resp, err
+}
+
+func (c *rateLimitChat) CreateStream(ctx context.Context, req *ChatRequest) (StreamReader, error) {
+	key := c.key(req)
+	reserved, err := c.l.acquire(ctx, key, c.l.cfg.EstimateTokens(req))
+	if err != nil {
+		return nil, err
+	}
+	stream, err := c.next.CreateStream(ctx, req)
+	if err != nil {
+		c.l.reconcile(key, reserved, 0)
+		return nil, err
+	}
+	return &rateLimitStream{StreamReader: stream, l: c.l, key: key, reserved: reserved}, nil
+}
+
+// rateLimitStream reconciles the token bucket with the last Usage reported by the stream.
+type rateLimitStream struct {
+	StreamReader
+	l        *rateLimiter
+	key      string
+	reserved int
+	usage    *Usage
+	once     sync.Once
+}
+
+func (s *rateLimitStream) done() {
+	s.once.Do(func() {
+		if s.usage != nil && s.usage.TotalTokens > 0 {
+			s.l.reconcile(s.key, s.reserved, s.usage.TotalTokens)
+		}
+	})
+}
+
+func (s *rateLimitStream) Next() (*StreamChunk, error) {
+	chunk, err := s.StreamReader.Next()
+	if chunk != nil && chunk.Usage != nil {
+		s.usage = chunk.Usage
+	}
+	if errors.Is(err, io.EOF) {
+		s.done()
+	}
+	return chunk, err
+}
+
+func (s *rateLimitStream) Close() error {
+	s.done()
+	return s.StreamReader.Close()
+}
+
+type rateLimitEmbedding struct {
+	next EmbeddingProvider
+	l    *rateLimiter
+}
+
+func (e *rateLimitEmbedding) Create(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error) {
+	key := e.l.cfg.Key
+	tokens := 0
+	if req != nil {
+		if key == "" {
+			key = req.Model
+		}
+		inputs, _ := embeddingInputs(req.Input)
+		for _, in := range inputs {
+			tokens += estimateTextTokens(in)
+		}
+	}
+	reserved, err := e.l.acquire(ctx, key, tokens)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := e.next.Create(ctx, req)
+	if err != nil {
+		e.l.reconcile(key, reserved, 0)
+	} else if resp.Usage != nil && resp.Usage.TotalTokens > 0 {
+		e.l.reconcile(key, reserved, resp.Usage.TotalTokens)
+	}
+	return resp, err
+}
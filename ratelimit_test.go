@@ -0,0 +1,167 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestRateLimitChat(p ChatProvider, cfg RateLimitConfig) (*rateLimitChat, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c := NewRateLimitChatProvider(p, cfg).(*rateLimitChat)
+	c.l.now = clock.now
+	return c, clock
+}
+
+func TestRateLimitChat_RequestsFailFast(t *testing.T) {
+	c, clock := newTestRateLimitChat(&MockChatProvider{}, RateLimitConfig{RequestsPerMinute: 2, FailFast: true})
+	ctx := context.Background()
+	req := &ChatRequest{Model: "m"}
+	for i := range 2 {
+		if _, err := c.Create(ctx, req); err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+	}
+	_, err := c.Create(ctx, req)
+	var rl *ErrRateLimitExceeded
+	if !errors.As(err, &rl) || rl.Limit != "requests" || rl.RetryAfter != 30*time.Second {
+		t.Fatalf("err = %v, want requests limit with 30s retry", err)
+	}
+	if _, err := c.Create(ctx, &ChatRequest{Model: "other"}); err != nil {
+		t.Errorf("other model should have its own bucket: %v", err)
+	}
+	clock.advance(30 * time.Second)
+	if _, err := c.Create(ctx, req); err != nil {
+		t.Errorf("after refill: %v", err)
+	}
+}
+
+func TestRateLimitChat_PerUser(t *testing.T) {
+	c, _ := newTestRateLimitChat(&MockChatProvider{}, RateLimitConfig{RequestsPerMinute: 1, PerUser: true, FailFast: true})
+	ctx := context.Background()
+	if _, err := c.Create(ctx, &ChatRequest{Model: "m", User: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Create(ctx, &ChatRequest{Model: "m", User: "b"}); err != nil {
+		t.Errorf("user b should have its own bucket: %v", err)
+	}
+	if _, err := c.Create(ctx, &ChatRequest{Model: "m", User: "a"}); !errors.Is(err, &ErrRateLimitExceeded{}) {
+		t.Errorf("err = %v, want ErrRateLimitExceeded", err)
+	}
+}
+
+func TestRateLimitChat_SweepsIdleBuckets(t *testing.T) {
+	c, clock := newTestRateLimitChat(&MockChatProvider{}, RateLimitConfig{RequestsPerMinute: 2, PerUser: true, FailFast: true})
+	ctx := context.Background()
+	for _, user := range []string{"a", "b", "c"} {
+		c.Create(ctx, &ChatRequest{Model: "m", User: user})
+	}
+	c.Create(ctx, &ChatRequest{Model: "m", User: "a"})
+	if n := len(c.l.buckets); n != 3 {
+		t.Fatalf("buckets = %d, want 3", n)
+	}
+
+	// After a minute b and c have refilled, while a is still catching up.
+	clock.advance(time.Minute - time.Second)
+	c.Create(ctx, &ChatRequest{Model: "m", User: "a"})
+	clock.advance(time.Second)
+	c.Create(ctx, &ChatRequest{Model: "m", User: "d"})
+	if _, ok := c.l.buckets[c.key(&ChatRequest{Model: "m", User: "a"})]; !ok || len(c.l.buckets) != 2 {
+		t.Errorf("buckets = %v, want a and d", c.l.buckets)
+	}
+}
+
+func TestRateLimitChat_Key(t *testing.T) {
+	c, _ := newTestRateLimitChat(&MockChatProvider{}, RateLimitConfig{RequestsPerMinute: 1, Key: "team", FailFast: true})
+	ctx := context.Background()
+	if _, err := c.Create(ctx, &ChatRequest{Model: "m"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Create(ctx, &ChatRequest{Model: "m-2025-01-01"}); !errors.Is(err, &ErrRateLimitExceeded{Key: "team"}) {
+		t.Errorf("err = %v, want the shared key to be exhausted", err)
+	}
+}
+
+func TestRateLimitChat_TokensReconciled(t *testing.T) {
+	actual := 0
+	mock := &MockChatProvider{CreateFunc: func(context.Context, *ChatRequest) (*ChatResponse, error) {
+		return &ChatResponse{Usage: &Usage{TotalTokens: actual}}, nil
+	}}
+	c, _ := newTestRateLimitChat(mock, RateLimitConfig{TokensPerMinute: 100, FailFast: true})
+	ctx := context.Background()
+	maxTokens := 40
+	req := &ChatRequest{Model: "m", Messages: []Message{{Role: "user", Content: "hi"}}, MaxTokens: &maxTokens}
+
+	// Estimated 45 tokens, but only 10 were used: the difference is refunded.
+	actual = 10
+	for i := range 3 {
+		if _, err := c.Create(ctx, req); err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+	}
+	// 70 left; this call reports 100, leaving the bucket in debt.
+	actual = 100
+	if _, err := c.Create(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Create(ctx, req); !errors.Is(err, &ErrRateLimitExceeded{Limit: "tokens"}) {
+		t.Errorf("err = %v, want tokens limit", err)
+	}
+}
+
+func TestRateLimitChat_FailedCallsRefunded(t *testing.T) {
+	mock := &MockChatProvider{
+		CreateFunc: func(context.Context, *ChatRequest) (*ChatResponse, error) {
+			return nil, errTransient
+		},
+		CreateStreamFunc: func(context.Context, *ChatRequest) (StreamReader, error) {
+			return nil, errTransient
+		},
+	}
+	c, _ := newTestRateLimitChat(mock, RateLimitConfig{TokensPerMinute: 100, FailFast: true})
+	ctx := context.Background()
+	maxTokens := 40
+	req := &ChatRequest{Model: "m", Messages: []Message{{Role: "user", Content: "hi"}}, MaxTokens: &maxTokens}
+
+	// Each call reserves 45 tokens; without refunds the third would exceed the budget.
+	for i := range 5 {
+		if _, err := c.Create(ctx, req); !errors.Is(err, errTransient) {
+			t.Fatalf("Create %d: %v", i, err)
+		}
+		if _, err := c.CreateStream(ctx, req); !errors.Is(err, errTransient) {
+			t.Fatalf("CreateStream %d: %v", i, err)
+		}
+	}
+}
+
+func TestRateLimitChat_Blocks(t *testing.T) {
+	// 6000 RPM refills one request every 10ms.
+	c := NewRateLimitChatProvider(&MockChatProvider{}, RateLimitConfig{RequestsPerMinute: 6000}).(*rateLimitChat)
+	ctx := context.Background()
+	c.Create(ctx, &ChatRequest{Model: "m"})
+	c.l.buckets["m"][0].level = 0
+	start := time.Now()
+	if _, err := c.Create(ctx, &ChatRequest{Model: "m"}); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 5*time.Millisecond {
+		t.Errorf("elapsed = %v, expected to wait for capacity", elapsed)
+	}
+
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	slow := NewRateLimitChatProvider(&MockChatProvider{}, RateLimitConfig{RequestsPerMinute: 1})
+	slow.Create(ctx, &ChatRequest{Model: "m"})
+	if _, err := slow.Create(cctx, &ChatRequest{Model: "m"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
+
+func TestEstimateChatTokens(t *testing.T) {
+	maxTokens := 10
+	got := EstimateChatTokens(&ChatRequest{Messages: []Message{{Role: "user", Content: "12345678"}}, MaxTokens: &maxTokens})
+	if got != 4+2+10 {
+		t.Errorf("EstimateChatTokens = %d, want 16", got)
+	}
+}
@@ -0,0 +1,150 @@
+package llm
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/MetaDiv-AI/logger"
+	"go.uber.org/zap"
+)
+
+// ProviderFactory creates a Client from the options passed to NewClient.
+type ProviderFactory func(Config) (*Client, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[Provider]ProviderFactory{
+		ProviderOpenRouter:       builtinProvider(newOpenRouterClient),
+		ProviderOpenAI:           builtinProvider(newOpenAIClient),
+		ProviderAnthropic:        builtinProvider(newAnthropicClient),
+		ProviderGemini:           builtinProvider(newGeminiClient),
+		ProviderOllama:           builtinProvider(newOllamaClient),
+		ProviderOpenAICompatible: builtinProvider(newOpenAICompatibleClient),
+	}
+)
+
+// builtinProvider adapts a built-in constructor. Transport-level retries are disabled and
+// replaced by the provider-agnostic retry layer so WithMaxRetries behaves the same on every backend.
+// Stream timeouts apply per attempt, below the retry layer, so first-chunk stalls are retried.
+func builtinProvider(newClient func(*config) (*Client, error)) ProviderFactory {
+	return func(c Config) (*Client, error) {
+		cfg := c.get()
+		inner := *cfg
+		inner.MaxRetries = 0
+		client, err := newClient(&inner)
+		if err != nil {
+			return nil, err
+		}
+		if client.Chat != nil && cfg.StreamTimeouts != nil {
+			client.Chat = timeoutChat{client.Chat, *cfg.StreamTimeouts}
+			cfg.streamTimeoutsApplied = true
+		}
+		retry := c.retryConfig()
+		client.Chat = NewRetryChatProvider(client.Chat, retry)
+		client.Embeddings = NewRetryEmbeddingProvider(client.Embeddings, retry)
+		return client, nil
+	}
+}
+
+// RegisterProvider makes a provider available to NewClient under name.
+// Registering an existing name replaces its factory, including built-in providers.
+// It panics if name is empty or factory is nil.
+func RegisterProvider(name Provider, factory func(Config) (*Client, error)) {
+	if name == "" {
+		panic("llm: RegisterProvider with empty name")
+	}
+	if factory == nil {
+		panic("llm: RegisterProvider with nil factory for " + string(name))
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[name] = factory
+}
+
+// Providers returns the names of all registered providers, sorted.
+func Providers() []Provider {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	out := make([]Provider, 0, len(registry))
+	for name := range registry {
+		out = append(out, name)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+func lookupProvider(name Provider) (ProviderFactory, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	f, ok := registry[name]
+	return f, ok
+}
+
+// Config is a read-only view of the options passed to NewClient, handed to provider factories.
+// The zero Config reports the defaults NewClient uses when no options are given.
+type Config struct {
+	c *config
+}
+
+func (c Config) get() *config {
+	if c.c == nil {
+		return newConfig()
+	}
+	return c.c
+}
+
+// APIKey returns the API key set with WithAPIKey.
+func (c Config) APIKey() string { return c.get().APIKey }
+
+// BaseURL returns the base URL set with WithBaseURL, or "" for the provider default.
+func (c Config) BaseURL() string { return c.get().BaseURL }
+
+// Timeout returns the HTTP client timeout.
+func (c Config) Timeout() time.Duration { return c.get().Timeout }
+
+// MaxRetries returns the maximum number of retries for retryable errors.
+func (c Config) MaxRetries() int { return c.get().MaxRetries }
+
+// Headers returns a copy of the custom headers.
+func (c Config) Headers() map[string]string {
+	headers := c.get().Headers
+	out := make(map[string]string, len(headers))
+	for k, v := range headers {
+		out[k] = v
+	}
+	return out
+}
+
+// Debug reports whether debug logging is enabled.
+func (c Config) Debug() bool { return c.get().Debug }
+
+// Logger returns the logger set with WithLogger, or nil.
+func (c Config) Logger() logger.Logger { return c.get().Logger }
+
+// Referer returns the HTTP-Referer header value set with WithReferer.
+func (c Config) Referer() string { return c.get().Referer }
+
+// Title returns the X-Title header value set with WithTitle.
+func (c Config) Title() string { return c.get().Title }
+
+// ForwardedFor returns the X-Forwarded-For header value set with WithForwardedFor.
+func (c Config) ForwardedFor() string { return c.get().ForwardedFor }
+
+// CompatQuirks returns the quirks set with WithCompatQuirks.
+func (c Config) CompatQuirks() CompatQuirks { return c.get().CompatQuirks }
+
+// RetryPolicy returns the policy set with WithRetryPolicy, or nil for DefaultRetryPolicy.
+func (c Config) RetryPolicy() RetryPolicy { return c.get().RetryPolicy }
+
+// retryConfig returns the RetryConfig derived from WithMaxRetries, WithRetryPolicy and the logger.
+func (c Config) retryConfig() RetryConfig {
+	cc := c.get()
+	cfg := RetryConfig{MaxRetries: cc.MaxRetries, Policy: cc.RetryPolicy}
+	if log := cc.Logger; log != nil {
+		cfg.OnRetry = func(attempt int, err error, delay time.Duration) {
+			log.Debug("llm: retrying request", zap.Int("attempt", attempt), zap.Duration("delay", delay), zap.Error(err))
+		}
+	}
+	return cfg
+}
@@ -0,0 +1,76 @@
+package llm
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRegisterProvider(t *testing.T) {
+	const name Provider = "test-registry"
+	var seen Config
+	RegisterProvider(name, func(c Config) (*Client, error) {
+		seen = c
+		return &Client{Chat: &MockChatProvider{}, Embeddings: &MockEmbeddingProvider{}}, nil
+	})
+	t.Cleanup(func() {
+		registryMu.Lock()
+		delete(registry, name)
+		registryMu.Unlock()
+	})
+
+	client, err := NewClient(name,
+		WithAPIKey("key"),
+		WithBaseURL("http://example.test"),
+		WithTimeout(5*time.Second),
+		WithMaxRetries(1),
+		WithHeaders(map[string]string{"X-A": "1"}),
+	)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	if _, ok := client.Chat.(*MockChatProvider); !ok {
+		t.Errorf("Chat = %T, want *MockChatProvider", client.Chat)
+	}
+	if seen.APIKey() != "key" || seen.BaseURL() != "http://example.test" || seen.Timeout() != 5*time.Second || seen.MaxRetries() != 1 {
+		t.Errorf("Config = key %q, url %q, timeout %v, retries %d", seen.APIKey(), seen.BaseURL(), seen.Timeout(), seen.MaxRetries())
+	}
+	headers := seen.Headers()
+	headers["X-A"] = "mutated"
+	if seen.Headers()["X-A"] != "1" {
+		t.Error("Headers() should return a copy")
+	}
+	if !slices.Contains(Providers(), name) {
+		t.Errorf("Providers() = %v, want to contain %q", Providers(), name)
+	}
+}
+
+func TestNewClient_UnknownProvider(t *testing.T) {
+	_, err := NewClient("does-not-exist")
+	if !errors.Is(err, &ErrUnknownProvider{Provider: "does-not-exist"}) {
+		t.Errorf("err = %v, want ErrUnknownProvider", err)
+	}
+}
+
+func TestRegisterProvider_PanicsOnNilFactory(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic")
+		}
+	}()
+	RegisterProvider("nil-factory", nil)
+}
+
+func TestConfig_Zero(t *testing.T) {
+	var cfg Config
+	if cfg.APIKey() != "" || cfg.BaseURL() != "" || cfg.Timeout() != DefaultTimeout || cfg.MaxRetries() != DefaultMaxRetries {
+		t.Errorf("zero Config = %q %q %v %d", cfg.APIKey(), cfg.BaseURL(), cfg.Timeout(), cfg.MaxRetries())
+	}
+	if h := cfg.Headers(); h == nil || len(h) != 0 {
+		t.Errorf("Headers() = %v", h)
+	}
+	if cfg.Logger() != nil || cfg.RetryPolicy() != nil {
+		t.Error("zero Config should have no logger or retry policy")
+	}
+}
@@ -0,0 +1,241 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"syscall"
+	"time"
+)
+
+// Default retry backoff settings used when RetryConfig fields are zero.
+const (
+	DefaultRetryInitialBackoff = 500 * time.Millisecond
+	DefaultRetryMaxBackoff     = 30 * time.Second
+	DefaultRetryMultiplier     = 2.0
+	DefaultRetryMaxRetryAfter  = 60 * time.Second
+)
+
+// RetryPolicy reports whether a failed attempt may be retried.
+type RetryPolicy func(err error) bool
+
+// RetryConfig configures NewRetryChatProvider and NewRetryEmbeddingProvider.
+type RetryConfig struct {
+	// MaxRetries is the number of retries after the first attempt. 0 disables retrying.
+	MaxRetries int
+	// InitialBackoff is the backoff ceiling before the first retry (default 500ms).
+	InitialBackoff time.Duration
+	// MaxBackoff caps the exponential backoff ceiling (default 30s).
+	MaxBackoff time.Duration
+	// Multiplier grows the backoff ceiling after each retry (default 2).
+	Multiplier float64
+	// MaxRetryAfter is the longest server-suggested Retry-After delay that is honored (default 60s).
+	// Errors asking for a longer wait are returned instead of retried.
+	MaxRetryAfter time.Duration
+	// Policy decides which errors are retryable (default DefaultRetryPolicy).
+	Policy RetryPolicy
+	// OnRetry, if set, is called before each retry with the 1-based retry number,
+	// the error that triggered it and the delay about to be waited.
+	OnRetry func(attempt int, err error, delay time.Duration)
+}
+
+func (c RetryConfig) withDefaults() RetryConfig {
+	if c.InitialBackoff <= 0 {
+		c.InitialBackoff = DefaultRetryInitialBackoff
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = DefaultRetryMaxBackoff
+	}
+	if c.Multiplier < 1 {
+		c.Multiplier = DefaultRetryMultiplier
+	}
+	if c.MaxRetryAfter <= 0 {
+		c.MaxRetryAfter = DefaultRetryMaxRetryAfter
+	}
+	if c.Policy == nil {
+		c.Policy = DefaultRetryPolicy
+	}
+	return c
+}
+
+// DefaultRetryPolicy retries rate limits, timeouts, server errors (408, 429, 5xx overload statuses),
+// streams stalled before their first chunk and transient network failures.
+// Validation errors and context cancellation are never retried.
+func DefaultRetryPolicy(err error) bool {
+	if err == nil || errors.Is(err, ErrInvalidRequest) {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var ae *APIError
+	if errors.As(err, &ae) {
+		return ae.retryable()
+	}
+	if errors.Is(err, &ErrStreamStalled{Phase: StallFirstChunk}) {
+		return true
+	}
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return true
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// retryAfter returns the server-suggested delay carried by err, if any.
+func retryAfter(err error) time.Duration {
+	var ae *APIError
+	if errors.As(err, &ae) {
+		return ae.RetryAfter
+	}
+	return 0
+}
+
+// retrier runs the shared attempt/backoff loop.
+type retrier struct {
+	cfg RetryConfig
+}
+
+// wait decides whether attempt (0-based count of retries already made) may be retried after err,
+// and if so sleeps for the Retry-After delay or an exponential backoff with full jitter.
+// It returns err (or ctx.Err()) when the request should not be retried.
+func (r *retrier) wait(ctx context.Context, attempt int, err error) error {
+	if attempt >= r.cfg.MaxRetries || !r.cfg.Policy(err) || ctx.Err() != nil {
+		return err
+	}
+	delay := retryAfter(err)
+	if delay > r.cfg.MaxRetryAfter {
+		return err
+	}
+	if delay <= 0 {
+		ceiling := float64(r.cfg.InitialBackoff) * math.Pow(r.cfg.Multiplier, float64(attempt))
+		if ceiling > float64(r.cfg.MaxBackoff) {
+			ceiling = float64(r.cfg.MaxBackoff)
+		}
+		delay = time.Duration(rand.Float64() * ceiling)
+	}
+	if r.cfg.OnRetry != nil {
+		r.cfg.OnRetry(attempt+1, err, delay)
+	}
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// NewRetryChatProvider wraps p with retries governed by cfg. Create is retried on retryable
+// errors; CreateStream is retried when opening the stream fails or when the stream fails
+// before its first chunk has been delivered to the caller.
+func NewRetryChatProvider(p ChatProvider, cfg RetryConfig) ChatProvider {
+	return &retryChat{next: p, r: &retrier{cfg: cfg.withDefaults()}}
+}
+
+// NewRetryEmbeddingProvider wraps p with retries governed by cfg.
+func NewRetryEmbeddingProvider(p EmbeddingProvider, cfg RetryConfig) EmbeddingProvider {
+	return &retryEmbedding{next: p, r: &retrier{cfg: cfg.withDefaults()}}
+}
+
+// RetryChatMiddleware returns a ChatMiddleware that applies NewRetryChatProvider.
+func RetryChatMiddleware(cfg RetryConfig) ChatMiddleware {
+	return func(p ChatProvider) ChatProvider { return NewRetryChatProvider(p, cfg) }
+}
+
+// RetryEmbeddingMiddleware returns an EmbeddingMiddleware that applies NewRetryEmbeddingProvider.
+func RetryEmbeddingMiddleware(cfg RetryConfig) EmbeddingMiddleware {
+	return func(p EmbeddingProvider) EmbeddingProvider { return NewRetryEmbeddingProvider(p, cfg) }
+}
+
+type retryChat struct {
+	next ChatProvider
+	r    *retrier
+}
+
+func (c *retryChat) Create(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := c.next.Create(ctx, req)
+		if err == nil {
+			return resp, nil
+		}
+		if werr := c.r.wait(ctx, attempt, err); werr != nil {
+			return nil, werr
+		}
+	}
+}
+
+func (c *retryChat) CreateStream(ctx context.Context, req *ChatRequest) (StreamReader, error) {
+	s := &retryStream{ctx: ctx, req: req, c: c}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// retryStream re-opens the upstream stream while no chunk has been delivered yet.
+type retryStream struct {
+	ctx     context.Context
+	req     *ChatRequest
+	c       *retryChat
+	cur     StreamReader
+	attempt int
+	started bool
+}
+
+func (s *retryStream) open() error {
+	for {
+		stream, err := s.c.next.CreateStream(s.ctx, s.req)
+		if err == nil {
+			s.cur = stream
+			return nil
+		}
+		if werr := s.c.r.wait(s.ctx, s.attempt, err); werr != nil {
+			return werr
+		}
+		s.attempt++
+	}
+}
+
+func (s *retryStream) Next() (*StreamChunk, error) {
+	for {
+		chunk, err := s.cur.Next()
+		if s.started || err == nil || chunk != nil || errors.Is(err, io.EOF) {
+			s.started = s.started || chunk != nil
+			return chunk, err
+		}
+		if werr := s.c.r.wait(s.ctx, s.attempt, err); werr != nil {
+			return nil, werr
+		}
+		s.attempt++
+		s.cur.Close()
+		if oerr := s.open(); oerr != nil {
+			return nil, oerr
+		}
+	}
+}
+
+func (s *retryStream) Close() error {
+	return s.cur.Close()
+}
+
+type retryEmbedding struct {
+	next EmbeddingProvider
+	r    *retrier
+}
+
+func (e *retryEmbedding) Create(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := e.next.Create(ctx, req)
+		if err == nil {
+			return resp, nil
+		}
+		if werr := e.r.wait(ctx, attempt, err); werr != nil {
+			return nil, werr
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+var errTransient = &APIError{Provider: "test", StatusCode: http.StatusServiceUnavailable}
+
+func fastRetry(n int) RetryConfig {
+	return RetryConfig{MaxRetries: n, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+}
+
+// sliceStream is a StreamReader over fixed chunks, optionally failing at position failAt.
+type sliceStream struct {
+	chunks []*StreamChunk
+	failAt int
+	err    error
+	pos    int
+	closed bool
+}
+
+func (s *sliceStream) Next() (*StreamChunk, error) {
+	if s.err != nil && s.pos == s.failAt {
+		return nil, s.err
+	}
+	if s.pos >= len(s.chunks) {
+		return nil, io.EOF
+	}
+	s.pos++
+	return s.chunks[s.pos-1], nil
+}
+
+func (s *sliceStream) Close() error {
+	s.closed = true
+	return nil
+}
+
+func textChunk(text string) *StreamChunk {
+	return &StreamChunk{Choices: []Choice{{Delta: &Message{Content: text}}}}
+}
+
+func TestRetryChat_Create(t *testing.T) {
+	calls := 0
+	var retries []int
+	mock := &MockChatProvider{CreateFunc: func(context.Context, *ChatRequest) (*ChatResponse, error) {
+		calls++
+		if calls < 3 {
+			return nil, errTransient
+		}
+		return &ChatResponse{ID: "ok"}, nil
+	}}
+	cfg := fastRetry(3)
+	cfg.OnRetry = func(attempt int, err error, delay time.Duration) { retries = append(retries, attempt) }
+	resp, err := NewRetryChatProvider(mock, cfg).Create(context.Background(), &ChatRequest{})
+	if err != nil || resp.ID != "ok" {
+		t.Fatalf("Create = %v, %v", resp, err)
+	}
+	if calls != 3 || len(retries) != 2 || retries[1] != 2 {
+		t.Errorf("calls = %d, retries = %v", calls, retries)
+	}
+}
+
+func TestRetryChat_NonRetryable(t *testing.T) {
+	calls := 0
+	mock := &MockChatProvider{CreateFunc: func(context.Context, *ChatRequest) (*ChatResponse, error) {
+		calls++
+		return nil, &APIError{Provider: "test", StatusCode: http.StatusBadRequest}
+	}}
+	if _, err := NewRetryChatProvider(mock, fastRetry(3)).Create(context.Background(), &ChatRequest{}); err == nil {
+		t.Fatal("expected error")
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestRetryChat_CustomPolicy(t *testing.T) {
+	calls := 0
+	sentinel := errors.New("flaky")
+	mock := &MockChatProvider{CreateFunc: func(context.Context, *ChatRequest) (*ChatResponse, error) {
+		calls++
+		return nil, sentinel
+	}}
+	cfg := fastRetry(2)
+	cfg.Policy = func(err error) bool { return errors.Is(err, sentinel) }
+	if _, err := NewRetryChatProvider(mock, cfg).Create(context.Background(), &ChatRequest{}); !errors.Is(err, sentinel) {
+		t.Fatalf("err = %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+}
+
+func TestRetryChat_RetryAfterTooLong(t *testing.T) {
+	calls := 0
+	mock := &MockChatProvider{CreateFunc: func(context.Context, *ChatRequest) (*ChatResponse, error) {
+		calls++
+		return nil, &APIError{Provider: "test", StatusCode: http.StatusTooManyRequests, RetryAfter: time.Hour}
+	}}
+	if _, err := NewRetryChatProvider(mock, fastRetry(3)).Create(context.Background(), &ChatRequest{}); err == nil {
+		t.Fatal("expected error")
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1 (Retry-After exceeds MaxRetryAfter)", calls)
+	}
+}
+
+func TestRetryChat_StreamBeforeFirstChunk(t *testing.T) {
+	var streams []*sliceStream
+	mock := &MockChatProvider{CreateStreamFunc: func(context.Context, *ChatRequest) (StreamReader, error) {
+		s := &sliceStream{chunks: []*StreamChunk{textChunk("a"), textChunk("b")}}
+		if len(streams) == 0 {
+			s.err, s.failAt = errTransient, 0
+		}
+		streams = append(streams, s)
+		return s, nil
+	}}
+	stream, err := NewRetryChatProvider(mock, fastRetry(2)).CreateStream(context.Background(), &ChatRequest{})
+	if err != nil {
+		t.Fatalf("CreateStream: %v", err)
+	}
+	var text string
+	for {
+		chunk, err := stream.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		text += chunk.Choices[0].Delta.Content.(string)
+	}
+	if text != "ab" || len(streams) != 2 || !streams[0].closed {
+		t.Errorf("text = %q, streams = %d, first closed = %v", text, len(streams), streams[0].closed)
+	}
+}
+
+func TestRetryChat_StreamAfterFirstChunkNotRetried(t *testing.T) {
+	opens := 0
+	mock := &MockChatProvider{CreateStreamFunc: func(context.Context, *ChatRequest) (StreamReader, error) {
+		opens++
+		return &sliceStream{chunks: []*StreamChunk{textChunk("a")}, err: errTransient, failAt: 1}, nil
+	}}
+	stream, err := NewRetryChatProvider(mock, fastRetry(2)).CreateStream(context.Background(), &ChatRequest{})
+	if err != nil {
+		t.Fatalf("CreateStream: %v", err)
+	}
+	if _, err := stream.Next(); err != nil {
+		t.Fatalf("first Next: %v", err)
+	}
+	if _, err := stream.Next(); !errors.Is(err, errTransient) {
+		t.Errorf("second Next err = %v, want transient error", err)
+	}
+	if opens != 1 {
+		t.Errorf("opens = %d, want 1", opens)
+	}
+}
+
+func TestRetryChat_StreamOpenError(t *testing.T) {
+	opens := 0
+	mock := &MockChatProvider{CreateStreamFunc: func(context.Context, *ChatRequest) (StreamReader, error) {
+		opens++
+		if opens == 1 {
+			return nil, errTransient
+		}
+		return &sliceStream{}, nil
+	}}
+	if _, err := NewRetryChatProvider(mock, fastRetry(1)).CreateStream(context.Background(), &ChatRequest{}); err != nil {
+		t.Fatalf("CreateStream: %v", err)
+	}
+	if opens != 2 {
+		t.Errorf("opens = %d, want 2", opens)
+	}
+}
+
+func TestRetryEmbedding_ContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	mock := &MockEmbeddingProvider{CreateFunc: func(context.Context, *EmbeddingRequest) (*EmbeddingResponse, error) {
+		calls++
+		cancel()
+		return nil, errTransient
+	}}
+	if _, err := NewRetryEmbeddingProvider(mock, fastRetry(3)).Create(ctx, &EmbeddingRequest{}); err == nil {
+		t.Fatal("expected error")
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+	}{
+		{"absent", http.Header{}, 0},
+		{"seconds", http.Header{"Retry-After": {"2"}}, 2 * time.Second},
+		{"date", http.Header{"Retry-After": {now.Add(5 * time.Second).Format(http.TimeFormat)}}, 5 * time.Second},
+		{"ms wins", http.Header{"Retry-After": {"2"}, "Retry-After-Ms": {"150"}}, 150 * time.Millisecond},
+		{"invalid", http.Header{"Retry-After": {"soon"}}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRetryAfter(tt.header, now); got != tt.want {
+				t.Errorf("parseRetryAfter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package llm
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SchemaFor returns the JSON Schema of T, which is usually a struct. See JSONSchema.
+func SchemaFor[T any]() map[string]any {
+	return JSONSchema(reflect.TypeFor[T]())
+}
+
+// JSONSchema reflects t into a JSON Schema object suitable for FunctionDef.Parameters and
+// JSONSchemaDef.Schema.
+//
+// Struct fields are named by their json tags; fields tagged json:"-" and unexported fields are
+// skipped, and embedded structs are flattened. A field is required unless its json tag has
+// omitempty or it is a pointer; the jsonschema tag can override this and add metadata:
+//
+//	type WeatherArgs struct {
+//		City string `json:"city" jsonschema:"description=City name, e.g. Paris"`
+//		Unit string `json:"unit,omitempty" jsonschema:"enum=celsius|fahrenheit,required"`
+//		Days *int   `json:"days" jsonschema:"description=Forecast length,optional"`
+//	}
+//
+// Supported jsonschema keys are description, enum (values separated by |), format, required
+// and optional. Commas inside a description are kept when the next segment is not a key.
+func JSONSchema(t reflect.Type) map[string]any {
+	return schemaOf(t, map[reflect.Type]bool{})
+}
+
+var (
+	timeType       = reflect.TypeFor[time.Time]()
+	rawMessageType = reflect.TypeFor[json.RawMessage]()
+)
+
+func schemaOf(t reflect.Type, visiting map[reflect.Type]bool) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case rawMessageType:
+		return map[string]any{}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]any{"type": "array", "items": schemaOf(t.Elem(), visiting)}
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return map[string]any{"type": "object"}
+		}
+		return map[string]any{"type": "object", "additionalProperties": schemaOf(t.Elem(), visiting)}
+	case reflect.Struct:
+		if visiting[t] {
+			// Recursive types cannot be expanded inline; accept any object.
+			return map[string]any{"type": "object"}
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+		props := map[string]any{}
+		required := []string{}
+		addStructFields(t, props, &required, visiting)
+		return map[string]any{
+			"type":                 "object",
+			"properties":           props,
+			"required":             required,
+			"additionalProperties": false,
+		}
+	}
+	return map[string]any{}
+}
+
+func addStructFields(t reflect.Type, props map[string]any, required *[]string, visiting map[reflect.Type]bool) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		jsonTag := f.Tag.Get("json")
+		if jsonTag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(jsonTag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				addStructFields(ft, props, required, visiting)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		s := schemaOf(f.Type, visiting)
+		isRequired := !strings.Contains(","+opts+",", ",omitempty,") && f.Type.Kind() != reflect.Pointer
+		for key, value := range parseSchemaTag(f.Tag.Get("jsonschema")) {
+			switch key {
+			case "description", "format":
+				s[key] = value
+			case "enum":
+				// An enum on a slice constrains its elements, not the slice.
+				target, t := s, f.Type
+				for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+					if t.Kind() != reflect.Pointer {
+						items, ok := target["items"].(map[string]any)
+						if !ok {
+							break
+						}
+						target = items
+					}
+					t = t.Elem()
+				}
+				target["enum"] = enumValues(t, strings.Split(value, "|"))
+			case "required":
+				isRequired = true
+			case "optional":
+				isRequired = false
+			}
+		}
+		props[name] = s
+		if isRequired {
+			*required = append(*required, name)
+		}
+	}
+}
+
+var schemaTagKeys = map[string]bool{"description": true, "enum": true, "format": true, "required": true, "optional": true}
+
+// parseSchemaTag parses `key=value,flag` pairs. Segments that do not start with a known key
+// continue the previous value, so descriptions may contain commas.
+func parseSchemaTag(tag string) map[string]string {
+	out := map[string]string{}
+	last := ""
+	for _, seg := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(seg, "=")
+		key = strings.TrimSpace(key)
+		if !schemaTagKeys[key] {
+			if last != "" {
+				out[last] += "," + seg
+			}
+			continue
+		}
+		out[key] = value
+		last = key
+	}
+	return out
+}
+
+// enumValues converts enum strings to the JSON type of t.
+func enumValues(t reflect.Type, values []string) []any {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	out := make([]any, len(values))
+	for i, v := range values {
+		out[i] = v
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+				out[i] = n
+			}
+		case reflect.Float32, reflect.Float64:
+			if n, err := strconv.ParseFloat(v, 64); err == nil {
+				out[i] = n
+			}
+		case reflect.Bool:
+			if b, err := strconv.ParseBool(v); err == nil {
+				out[i] = b
+			}
+		}
+	}
+	return out
+}
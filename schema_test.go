@@ -0,0 +1,98 @@
+package llm
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type schemaAddress struct {
+	Street string `json:"street"`
+}
+
+type schemaBase struct {
+	ID string `json:"id"`
+}
+
+type schemaNode struct {
+	Children []*schemaNode `json:"children,omitempty"`
+}
+
+type schemaArgs struct {
+	schemaBase
+	City     string            `json:"city" jsonschema:"description=City name, e.g. Paris"`
+	Unit     string            `json:"unit,omitempty" jsonschema:"enum=celsius|fahrenheit,required"`
+	Days     *int              `json:"days"`
+	Level    int               `json:"level" jsonschema:"enum=1|2|3,optional"`
+	Tags     []string          `json:"tags"`
+	Modes    []string          `json:"modes,omitempty" jsonschema:"enum=fast|slow"`
+	Home     schemaAddress     `json:"home"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	When     time.Time         `json:"when" jsonschema:"description=Start time"`
+	Tree     schemaNode        `json:"tree,omitempty"`
+	Ignored  string            `json:"-"`
+	internal string
+}
+
+func TestSchemaFor(t *testing.T) {
+	s := SchemaFor[schemaArgs]()
+	if s["type"] != "object" || s["additionalProperties"] != false {
+		t.Fatalf("schema = %v", s)
+	}
+	props := s["properties"].(map[string]any)
+	for _, name := range []string{"Ignored", "internal"} {
+		if _, ok := props[name]; ok {
+			t.Errorf("%s should be skipped", name)
+		}
+	}
+	if props["id"] == nil {
+		t.Error("embedded struct fields should be flattened")
+	}
+	city := props["city"].(map[string]any)
+	if city["type"] != "string" || city["description"] != "City name, e.g. Paris" {
+		t.Errorf("city = %v", city)
+	}
+	if got := props["unit"].(map[string]any)["enum"]; !reflect.DeepEqual(got, []any{"celsius", "fahrenheit"}) {
+		t.Errorf("unit enum = %v", got)
+	}
+	if got := props["level"].(map[string]any)["enum"]; !reflect.DeepEqual(got, []any{int64(1), int64(2), int64(3)}) {
+		t.Errorf("level enum = %v", got)
+	}
+	if got := props["tags"]; !reflect.DeepEqual(got, map[string]any{"type": "array", "items": map[string]any{"type": "string"}}) {
+		t.Errorf("tags = %v", got)
+	}
+	if got := props["modes"]; !reflect.DeepEqual(got, map[string]any{"type": "array", "items": map[string]any{"type": "string", "enum": []any{"fast", "slow"}}}) {
+		t.Errorf("modes = %v, want the enum on items", got)
+	}
+	if got := props["when"].(map[string]any); got["format"] != "date-time" || got["description"] != "Start time" {
+		t.Errorf("when = %v", got)
+	}
+	if got := props["labels"].(map[string]any)["additionalProperties"]; !reflect.DeepEqual(got, map[string]any{"type": "string"}) {
+		t.Errorf("labels = %v", got)
+	}
+	home := props["home"].(map[string]any)
+	if !reflect.DeepEqual(home["required"], []string{"street"}) {
+		t.Errorf("home = %v", home)
+	}
+	children := props["tree"].(map[string]any)["properties"].(map[string]any)["children"].(map[string]any)
+	if !reflect.DeepEqual(children["items"], map[string]any{"type": "object"}) {
+		t.Errorf("recursive children = %v", children)
+	}
+
+	want := []string{"id", "city", "unit", "tags", "home", "when"}
+	if !reflect.DeepEqual(s["required"], want) {
+		t.Errorf("required = %v, want %v", s["required"], want)
+	}
+	if _, err := json.Marshal(s); err != nil {
+		t.Errorf("schema does not marshal: %v", err)
+	}
+}
+
+func TestParseSchemaTag(t *testing.T) {
+	got := parseSchemaTag("description=a, b,c=d,enum=x|y,optional")
+	want := map[string]string{"description": "a, b,c=d", "enum": "x|y", "optional": ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseSchemaTag = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,64 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+)
+
+// maxSSELineSize bounds a single streamed line (large tool-call arguments and base64 payloads).
+const maxSSELineSize = 4 << 20
+
+// sseEvent is a single Server-Sent Event.
+type sseEvent struct {
+	Event string
+	Data  []byte
+}
+
+// sseDecoder reads Server-Sent Events from a response body.
+type sseDecoder struct {
+	scanner *bufio.Scanner
+}
+
+func newSSEDecoder(r io.Reader) *sseDecoder {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), maxSSELineSize)
+	return &sseDecoder{scanner: s}
+}
+
+// Next returns the next event with a non-empty data field, or io.EOF when the body is exhausted.
+// Comment lines and fields other than "event" and "data" are ignored.
+func (d *sseDecoder) Next() (*sseEvent, error) {
+	var (
+		event string
+		data  [][]byte
+	)
+	for d.scanner.Scan() {
+		line := d.scanner.Bytes()
+		if len(line) == 0 {
+			if len(data) > 0 {
+				return &sseEvent{Event: event, Data: bytes.Join(data, []byte("\n"))}, nil
+			}
+			event = ""
+			continue
+		}
+		if line[0] == ':' {
+			continue
+		}
+		field, value, _ := bytes.Cut(line, []byte(":"))
+		value = bytes.TrimPrefix(value, []byte(" "))
+		switch string(field) {
+		case "event":
+			event = string(value)
+		case "data":
+			data = append(data, append([]byte(nil), value...))
+		}
+	}
+	if err := d.scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(data) > 0 {
+		return &sseEvent{Event: event, Data: bytes.Join(data, []byte("\n"))}, nil
+	}
+	return nil, io.EOF
+}
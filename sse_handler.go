@@ -0,0 +1,172 @@
+package llm
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+)
+
+// DefaultSSEHeartbeat is the default interval between SSE keep-alive comments.
+const DefaultSSEHeartbeat = 15 * time.Second
+
+// SSEOptions configures WriteSSE and NewSSEHandler.
+type SSEOptions struct {
+	// Heartbeat is the interval of ": ping" comments sent while waiting for chunks,
+	// keeping proxies from closing idle connections (default 15s; negative disables).
+	Heartbeat time.Duration
+}
+
+// sseErrorFrame is the OpenAI-style error object written to the stream or response body.
+type sseErrorFrame struct {
+	Error sseErrorBody `json:"error"`
+}
+
+type sseErrorBody struct {
+	Message string `json:"message"`
+	Code    string `json:"code,omitempty"`
+}
+
+func newSSEErrorFrame(err error) sseErrorFrame {
+	frame := sseErrorFrame{Error: sseErrorBody{Message: err.Error()}}
+	var ae *APIError
+	if errors.As(err, &ae) {
+		frame.Error.Code = ae.Code
+	}
+	return frame
+}
+
+// WriteSSE writes stream to w as OpenAI-compatible server-sent events: one "data:" frame per
+// chunk, flushed immediately, followed by "data: [DONE]". A stream error is written as a
+// "data: {"error":{...}}" frame without the terminator and returned. When the client
+// disconnects (r's context is done) the context error is returned.
+//
+// stream is always closed, but only after any pending Next has returned, so it should be
+// opened with r's context: a disconnect then also aborts the upstream read.
+func WriteSSE(w http.ResponseWriter, r *http.Request, stream StreamReader, opts SSEOptions) error {
+	rc := http.NewResponseController(w)
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	h.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	_ = rc.Flush()
+
+	type result struct {
+		chunk *StreamChunk
+		err   error
+	}
+	results := make(chan result)
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	defer func() {
+		close(done)
+		<-exited
+		stream.Close()
+	}()
+	go func() {
+		defer close(exited)
+		for {
+			chunk, err := stream.Next()
+			select {
+			case results <- result{chunk, err}:
+			case <-done:
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	var tick <-chan time.Time
+	heartbeat := opts.Heartbeat
+	if heartbeat == 0 {
+		heartbeat = DefaultSSEHeartbeat
+	}
+	if heartbeat > 0 {
+		t := time.NewTicker(heartbeat)
+		defer t.Stop()
+		tick = t.C
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return r.Context().Err()
+		case <-tick:
+			if _, err := io.WriteString(w, ": ping\n\n"); err != nil {
+				return err
+			}
+			_ = rc.Flush()
+		case res := <-results:
+			// A stream opened with the request context fails once the client
+			// disconnects; report the disconnect rather than the read error.
+			if err := r.Context().Err(); err != nil && res.err != nil {
+				return err
+			}
+			if res.chunk != nil {
+				if err := writeSSEData(w, res.chunk); err != nil {
+					return err
+				}
+			}
+			switch {
+			case errors.Is(res.err, io.EOF):
+				_, err := io.WriteString(w, "data: [DONE]\n\n")
+				_ = rc.Flush()
+				return err
+			case res.err != nil:
+				_ = writeSSEData(w, newSSEErrorFrame(res.err))
+				_ = rc.Flush()
+				return res.err
+			}
+			_ = rc.Flush()
+		}
+	}
+}
+
+func writeSSEData(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, "data: "); err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n\n")
+	return err
+}
+
+// NewSSEHandler returns an http.Handler that opens a stream with open and proxies it to the
+// client with WriteSSE. If open fails, the error is written as a JSON error response with the
+// provider's HTTP status (400 for invalid requests, 502 otherwise).
+func NewSSEHandler(open func(r *http.Request) (StreamReader, error), opts SSEOptions) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stream, err := open(r)
+		if err != nil {
+			writeJSONError(w, err)
+			return
+		}
+		_ = WriteSSE(w, r, stream, opts)
+	})
+}
+
+// writeJSONError writes err as an OpenAI-style JSON error response.
+func writeJSONError(w http.ResponseWriter, err error) {
+	status := http.StatusBadGateway
+	var ae *APIError
+	switch {
+	case errors.Is(err, ErrInvalidRequest):
+		status = http.StatusBadRequest
+	case errors.As(err, &ae) && ae.StatusCode >= 400:
+		status = ae.StatusCode
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(newSSEErrorFrame(err))
+}
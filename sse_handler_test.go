@@ -0,0 +1,170 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// blockingStream blocks in Next until closed.
+type blockingStream struct {
+	closed chan struct{}
+}
+
+func (s *blockingStream) Next() (*StreamChunk, error) {
+	<-s.closed
+	return nil, io.ErrClosedPipe
+}
+
+func (s *blockingStream) Close() error {
+	select {
+	case <-s.closed:
+	default:
+		close(s.closed)
+	}
+	return nil
+}
+
+// newContextStream returns a blockingStream that is closed when ctx is done, like a
+// provider stream opened with ctx.
+func newContextStream(ctx context.Context) *blockingStream {
+	s := &blockingStream{closed: make(chan struct{})}
+	go func() {
+		<-ctx.Done()
+		s.Close()
+	}()
+	return s
+}
+
+func TestWriteSSE(t *testing.T) {
+	stream := &MockStreamReader{Chunks: []*StreamChunk{textChunk("Hel"), textChunk("lo")}}
+	rec := httptest.NewRecorder()
+	if err := WriteSSE(rec, httptest.NewRequest("GET", "/", nil), stream, SSEOptions{}); err != nil {
+		t.Fatalf("WriteSSE: %v", err)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if !stream.Closed {
+		t.Error("stream should be closed")
+	}
+	dec := newSSEDecoder(rec.Body)
+	var text string
+	for {
+		ev, err := dec.Next()
+		if err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if string(ev.Data) == "[DONE]" {
+			break
+		}
+		var chunk StreamChunk
+		if err := json.Unmarshal(ev.Data, &chunk); err != nil {
+			t.Fatal(err)
+		}
+		text += chunk.Choices[0].Delta.Content.(string)
+	}
+	if text != "Hello" {
+		t.Errorf("text = %q", text)
+	}
+}
+
+func TestWriteSSE_Error(t *testing.T) {
+	stream := &MockStreamReader{Err: &APIError{Provider: "test", Code: "overloaded_error", Message: "busy"}}
+	rec := httptest.NewRecorder()
+	if err := WriteSSE(rec, httptest.NewRequest("GET", "/", nil), stream, SSEOptions{}); err == nil {
+		t.Fatal("expected error")
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, `"code":"overloaded_error"`) || strings.Contains(body, "[DONE]") {
+		t.Errorf("body = %q", body)
+	}
+}
+
+func TestWriteSSE_HeartbeatAndDisconnect(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := newContextStream(ctx)
+	rec := httptest.NewRecorder()
+	done := make(chan error, 1)
+	go func() {
+		done <- WriteSSE(rec, httptest.NewRequest("GET", "/", nil).WithContext(ctx), stream, SSEOptions{Heartbeat: time.Millisecond})
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("err = %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("WriteSSE did not return after disconnect")
+	}
+	select {
+	case <-stream.closed:
+	default:
+		t.Error("stream should be closed on disconnect")
+	}
+	if !strings.Contains(rec.Body.String(), ": ping\n\n") {
+		t.Error("expected heartbeat comments")
+	}
+}
+
+func TestNewSSEHandler_OpenError(t *testing.T) {
+	h := NewSSEHandler(func(r *http.Request) (StreamReader, error) {
+		return nil, &APIError{Provider: "test", StatusCode: http.StatusTooManyRequests, Message: "slow down"}
+	}, SSEOptions{})
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("status = %d, want 429", resp.StatusCode)
+	}
+}
+
+func TestNewSSEHandler_DisconnectMidStream(t *testing.T) {
+	client := newTestOpenAIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "data: {\"id\":\"c1\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hel\"}}]}\n\n")
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	})
+	served := make(chan struct{})
+	h := NewSSEHandler(func(r *http.Request) (StreamReader, error) {
+		return client.Chat.CreateStream(r.Context(), &ChatRequest{Model: "gpt-4o", Messages: []Message{{Role: "user", Content: "hi"}}})
+	}, SSEOptions{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(served)
+		h.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev, err := newSSEDecoder(resp.Body).Next(); err != nil || !strings.Contains(string(ev.Data), "Hel") {
+		t.Fatalf("first event = %+v, %v", ev, err)
+	}
+	cancel()
+	resp.Body.Close()
+	select {
+	case <-served:
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler did not return after disconnect")
+	}
+}
@@ -0,0 +1,87 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// StreamError is returned by a strict StreamReader when a streamed Choice carries a ChoiceError.
+// Like APIError, it matches the category sentinels (ErrRateLimited, ...) with errors.Is.
+type StreamError struct {
+	// Index is the index of the failing choice.
+	Index   int
+	Code    int
+	Message string
+	// Content is the text content received for the choice before the error.
+	Content string
+}
+
+func (e *StreamError) Error() string {
+	return fmt.Sprintf("llm: stream error (code %d): %s", e.Code, e.Message)
+}
+
+// Is reports whether target is the error category of e.
+func (e *StreamError) Is(target error) bool {
+	c := (&APIError{StatusCode: e.Code, Message: e.Message}).Category()
+	return c != nil && target == c
+}
+
+// NewStrictStreamReader wraps r so that Next returns a *StreamError as soon as a chunk
+// carries a Choice with a non-nil Error, instead of passing the error along in the chunk.
+// Once an error is returned, later calls to Next return it again.
+func NewStrictStreamReader(r StreamReader) StreamReader {
+	return &strictStream{StreamReader: r, content: make(map[int]*strings.Builder)}
+}
+
+type strictStream struct {
+	StreamReader
+	content map[int]*strings.Builder
+	err     error
+}
+
+func (s *strictStream) Next() (*StreamChunk, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	chunk, err := s.StreamReader.Next()
+	if chunk == nil {
+		return chunk, err
+	}
+	for _, ch := range chunk.Choices {
+		b := s.content[ch.Index]
+		if b == nil {
+			b = &strings.Builder{}
+			s.content[ch.Index] = b
+		}
+		if ch.Delta != nil {
+			b.WriteString(contentText(ch.Delta.Content))
+		}
+		if ch.Error != nil {
+			s.err = &StreamError{Index: ch.Index, Code: ch.Error.Code, Message: ch.Error.Message, Content: b.String()}
+			return nil, s.err
+		}
+	}
+	return chunk, err
+}
+
+// strictChat applies NewStrictStreamReader to every stream.
+type strictChat struct {
+	ChatProvider
+}
+
+func (c strictChat) CreateStream(ctx context.Context, req *ChatRequest) (StreamReader, error) {
+	stream, err := c.ChatProvider.CreateStream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return NewStrictStreamReader(stream), nil
+}
+
+// WithStrictStream makes Client.Chat streams return a *StreamError from Next when a chunk
+// carries a ChoiceError, rather than delivering the error inside the chunk.
+func WithStrictStream(strict bool) Option {
+	return func(c *config) {
+		c.StrictStream = strict
+	}
+}
@@ -0,0 +1,78 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+func errorChunk(code int, msg string) *StreamChunk {
+	return &StreamChunk{Choices: []Choice{{Delta: &Message{Content: "!"}, Error: &ChoiceError{Code: code, Message: msg}}}}
+}
+
+func TestStrictStream_ChoiceError(t *testing.T) {
+	mock := &MockChatProvider{
+		StreamChunks: []*StreamChunk{textChunk("Hel"), textChunk("lo"), errorChunk(429, "rate limited upstream")},
+		StrictStream: true,
+	}
+	stream, err := mock.CreateStream(context.Background(), &ChatRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	for range 2 {
+		if _, err := stream.Next(); err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+	}
+	_, err = stream.Next()
+	var se *StreamError
+	if !errors.As(err, &se) {
+		t.Fatalf("err = %v, want *StreamError", err)
+	}
+	if se.Code != 429 || se.Content != "Hello!" {
+		t.Errorf("StreamError = %+v", se)
+	}
+	if !errors.Is(err, ErrRateLimited) {
+		t.Error("StreamError should match ErrRateLimited")
+	}
+	if _, again := stream.Next(); again != err {
+		t.Errorf("Next after error = %v, want the same error", again)
+	}
+}
+
+func TestStrictStream_Lenient(t *testing.T) {
+	mock := &MockChatProvider{StreamChunks: []*StreamChunk{errorChunk(500, "boom")}}
+	stream, _ := mock.CreateStream(context.Background(), &ChatRequest{})
+	chunk, err := stream.Next()
+	if err != nil || chunk.Choices[0].Error == nil {
+		t.Fatalf("Next = %+v, %v; want chunk carrying the error", chunk, err)
+	}
+	if _, err := stream.Next(); !errors.Is(err, io.EOF) {
+		t.Errorf("err = %v, want io.EOF", err)
+	}
+}
+
+func TestWithStrictStream(t *testing.T) {
+	name := Provider("test-strict")
+	RegisterProvider(name, func(Config) (*Client, error) {
+		return &Client{Chat: &MockChatProvider{StreamChunks: []*StreamChunk{errorChunk(503, "overloaded")}}}, nil
+	})
+	t.Cleanup(func() {
+		registryMu.Lock()
+		delete(registry, name)
+		registryMu.Unlock()
+	})
+	client, err := NewClient(name, WithStrictStream(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream, err := client.Chat.CreateStream(context.Background(), &ChatRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Next(); !errors.Is(err, ErrProviderUnavailable) {
+		t.Errorf("err = %v, want StreamError matching ErrProviderUnavailable", err)
+	}
+}
@@ -0,0 +1,158 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Stream stall phases reported by ErrStreamStalled.
+const (
+	StallFirstChunk = "first chunk"
+	StallIdle       = "idle"
+)
+
+// ErrStreamStalled is returned by StreamReader.Next when a stream exceeds its first-chunk
+// deadline or inter-chunk idle timeout. The upstream connection is closed.
+type ErrStreamStalled struct {
+	// Phase is StallFirstChunk or StallIdle.
+	Phase   string
+	Timeout time.Duration
+}
+
+func (e *ErrStreamStalled) Error() string {
+	return fmt.Sprintf("llm: stream stalled: %s timeout of %s exceeded", e.Phase, e.Timeout)
+}
+
+// Is supports errors.Is for ErrStreamStalled.
+func (e *ErrStreamStalled) Is(target error) bool {
+	t, ok := target.(*ErrStreamStalled)
+	return ok && (t == nil || t.Phase == "" || t.Phase == e.Phase)
+}
+
+// StreamTimeouts bounds how long a stream may go without data. Zero disables a timeout.
+type StreamTimeouts struct {
+	// FirstChunk is the deadline for the first chunk after the stream is opened.
+	FirstChunk time.Duration
+	// Idle is the longest allowed gap between later chunks.
+	Idle time.Duration
+}
+
+func (t StreamTimeouts) enabled() bool {
+	return t.FirstChunk > 0 || t.Idle > 0
+}
+
+// WithStreamTimeouts sets client-wide stream timeouts for Client.Chat. Unlike WithTimeout,
+// which bounds the whole HTTP exchange, these only fire when a stream stops producing chunks.
+// It also enables per-request overrides with ContextWithStreamTimeouts; pass a zero
+// StreamTimeouts to allow overrides without a client-wide default.
+func WithStreamTimeouts(t StreamTimeouts) Option {
+	return func(c *config) {
+		c.StreamTimeouts = &t
+	}
+}
+
+type streamTimeoutsKey struct{}
+
+// ContextWithStreamTimeouts returns a context that overrides the client's stream timeouts
+// for CreateStream calls made with it. The client must be created with WithStreamTimeouts.
+func ContextWithStreamTimeouts(ctx context.Context, t StreamTimeouts) context.Context {
+	return context.WithValue(ctx, streamTimeoutsKey{}, t)
+}
+
+// NewTimeoutStreamReader wraps r so that Next returns ErrStreamStalled and closes r when
+// no chunk arrives within t.FirstChunk (for the first chunk) or t.Idle (for later chunks).
+//
+// cancel should cancel the context r was opened with. On a stall, Next cancels it, waits
+// for the pending r.Next to return and only then closes r, so r never sees Close and Next
+// at the same time. If cancel is nil, r.Close is called while r.Next is still pending and
+// must be safe for that.
+func NewTimeoutStreamReader(r StreamReader, t StreamTimeouts, cancel context.CancelFunc) StreamReader {
+	if !t.enabled() {
+		return r
+	}
+	return &timeoutStream{StreamReader: r, t: t, cancel: cancel}
+}
+
+type timeoutStream struct {
+	StreamReader
+	t       StreamTimeouts
+	cancel  context.CancelFunc
+	started bool
+	err     error
+}
+
+type streamResult struct {
+	chunk *StreamChunk
+	err   error
+}
+
+func (s *timeoutStream) Next() (*StreamChunk, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	timeout, phase := s.t.Idle, StallIdle
+	if !s.started {
+		timeout, phase = s.t.FirstChunk, StallFirstChunk
+	}
+	if timeout <= 0 {
+		return s.record(s.StreamReader.Next())
+	}
+	ch := make(chan streamResult, 1)
+	go func() {
+		chunk, err := s.StreamReader.Next()
+		ch <- streamResult{chunk, err}
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case res := <-ch:
+		return s.record(res.chunk, res.err)
+	case <-timer.C:
+		s.err = &ErrStreamStalled{Phase: phase, Timeout: timeout}
+		if s.cancel != nil {
+			s.cancel()
+			<-ch
+		}
+		s.StreamReader.Close()
+		return nil, s.err
+	}
+}
+
+func (s *timeoutStream) Close() error {
+	err := s.StreamReader.Close()
+	if s.cancel != nil {
+		s.cancel()
+	}
+	return err
+}
+
+func (s *timeoutStream) record(chunk *StreamChunk, err error) (*StreamChunk, error) {
+	if chunk != nil {
+		s.started = true
+	}
+	return chunk, err
+}
+
+// timeoutChat applies the client's or the request context's StreamTimeouts to every stream.
+type timeoutChat struct {
+	ChatProvider
+	defaults StreamTimeouts
+}
+
+func (c timeoutChat) CreateStream(ctx context.Context, req *ChatRequest) (StreamReader, error) {
+	t := c.defaults
+	if v, ok := ctx.Value(streamTimeoutsKey{}).(StreamTimeouts); ok {
+		t = v
+	}
+	if !t.enabled() {
+		return c.ChatProvider.CreateStream(ctx, req)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	stream, err := c.ChatProvider.CreateStream(ctx, req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return NewTimeoutStreamReader(stream, t, cancel), nil
+}
@@ -0,0 +1,154 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// slowStream delivers chunks after the given delays, then io.EOF.
+type slowStream struct {
+	MockStreamReader
+	delays []time.Duration
+	i      int
+}
+
+func (s *slowStream) Next() (*StreamChunk, error) {
+	if s.i < len(s.delays) {
+		time.Sleep(s.delays[s.i])
+		s.i++
+	}
+	return s.MockStreamReader.Next()
+}
+
+func TestTimeoutStream_FirstChunk(t *testing.T) {
+	upstream := &blockingStream{closed: make(chan struct{})}
+	stream := NewTimeoutStreamReader(upstream, StreamTimeouts{FirstChunk: 10 * time.Millisecond}, nil)
+	_, err := stream.Next()
+	if !errors.Is(err, &ErrStreamStalled{Phase: StallFirstChunk}) {
+		t.Fatalf("err = %v, want first-chunk stall", err)
+	}
+	select {
+	case <-upstream.closed:
+	default:
+		t.Error("upstream should be closed on stall")
+	}
+	if !DefaultRetryPolicy(err) {
+		t.Error("first-chunk stalls should be retryable")
+	}
+}
+
+func TestTimeoutStream_Idle(t *testing.T) {
+	upstream := &slowStream{
+		MockStreamReader: MockStreamReader{Chunks: []*StreamChunk{textChunk("a"), textChunk("b")}},
+		delays:           []time.Duration{0, 200 * time.Millisecond},
+	}
+	stream := NewTimeoutStreamReader(upstream, StreamTimeouts{FirstChunk: time.Second, Idle: 20 * time.Millisecond}, nil)
+	if _, err := stream.Next(); err != nil {
+		t.Fatalf("first Next: %v", err)
+	}
+	_, err := stream.Next()
+	var stalled *ErrStreamStalled
+	if !errors.As(err, &stalled) || stalled.Phase != StallIdle || stalled.Timeout != 20*time.Millisecond {
+		t.Fatalf("err = %v, want idle stall", err)
+	}
+	if DefaultRetryPolicy(err) {
+		t.Error("idle stalls should not be retried")
+	}
+}
+
+func TestWithStreamTimeouts(t *testing.T) {
+	name := Provider("test-stall")
+	RegisterProvider(name, func(Config) (*Client, error) {
+		return &Client{Chat: &MockChatProvider{CreateStreamFunc: func(ctx context.Context, _ *ChatRequest) (StreamReader, error) {
+			s := &blockingStream{closed: make(chan struct{})}
+			go func() {
+				<-ctx.Done()
+				s.Close()
+			}()
+			return s, nil
+		}}}, nil
+	})
+	t.Cleanup(func() {
+		registryMu.Lock()
+		delete(registry, name)
+		registryMu.Unlock()
+	})
+	client, err := NewClient(name, WithStreamTimeouts(StreamTimeouts{FirstChunk: time.Hour}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := ContextWithStreamTimeouts(context.Background(), StreamTimeouts{FirstChunk: 10 * time.Millisecond})
+	stream, err := client.Chat.CreateStream(ctx, &ChatRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	if _, err := stream.Next(); !errors.Is(err, &ErrStreamStalled{}) {
+		t.Errorf("err = %v, want per-request stall", err)
+	}
+}
+
+func TestTimeoutStream_HTTPStall(t *testing.T) {
+	client := newTestOpenAIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "data: {\"id\":\"c1\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hel\"}}]}\n\n")
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}, WithStreamTimeouts(StreamTimeouts{Idle: 50 * time.Millisecond}))
+
+	stream, err := client.Chat.CreateStream(context.Background(), &ChatRequest{
+		Model:    "gpt-4o",
+		Messages: []Message{{Role: "user", Content: "hi"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateStream: %v", err)
+	}
+	defer stream.Close()
+	if _, err := stream.Next(); err != nil {
+		t.Fatalf("first Next: %v", err)
+	}
+	if _, err := stream.Next(); !errors.Is(err, &ErrStreamStalled{Phase: StallIdle}) {
+		t.Fatalf("err = %v, want idle stall", err)
+	}
+}
+
+func TestWithStreamTimeouts_RetriesFirstChunkStall(t *testing.T) {
+	var calls atomic.Int32
+	client := newTestOpenAIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.(http.Flusher).Flush()
+		if calls.Add(1) == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+			return
+		}
+		io.WriteString(w, "data: {\"id\":\"c1\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hi\"}}]}\n\n")
+		io.WriteString(w, "data: [DONE]\n\n")
+	}, WithStreamTimeouts(StreamTimeouts{FirstChunk: 50 * time.Millisecond}), WithMaxRetries(2))
+
+	stream, err := client.Chat.CreateStream(context.Background(), &ChatRequest{
+		Model:    "gpt-4o",
+		Messages: []Message{{Role: "user", Content: "hi"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateStream: %v", err)
+	}
+	defer stream.Close()
+	chunk, err := stream.Next()
+	if err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	if chunk.Choices[0].Delta.Content != "Hi" || calls.Load() != 2 {
+		t.Errorf("chunk = %+v after %d requests, want the retried stream", chunk, calls.Load())
+	}
+}
@@ -0,0 +1,364 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// DefaultStructuredRepairs is how many times CreateStructured re-prompts after a reply that
+// does not parse or validate.
+const DefaultStructuredRepairs = 2
+
+// StructuredOption configures CreateStructured.
+type StructuredOption func(*structuredConfig)
+
+type structuredConfig struct {
+	repairs int
+	name    string
+}
+
+// WithStructuredRepairs sets how many times CreateStructured re-prompts after an invalid
+// reply (default DefaultStructuredRepairs). 0 disables re-prompting.
+func WithStructuredRepairs(n int) StructuredOption {
+	return func(c *structuredConfig) {
+		if n >= 0 {
+			c.repairs = n
+		}
+	}
+}
+
+// WithSchemaName sets JSONSchemaDef.Name (default: the Go type name).
+func WithSchemaName(name string) StructuredOption {
+	return func(c *structuredConfig) {
+		c.name = name
+	}
+}
+
+// Validator can be implemented by structured output types to reject values that parse but
+// are not acceptable. CreateStructured re-prompts with the returned error.
+type Validator interface {
+	Validate() error
+}
+
+// StructuredError is returned by CreateStructured when no reply could be parsed into the
+// target type. Content is the last reply and Err the last parse or validation error.
+type StructuredError struct {
+	Content  string
+	Attempts int
+	Err      error
+}
+
+func (e *StructuredError) Error() string {
+	return fmt.Sprintf("llm: structured output invalid after %d attempts: %v", e.Attempts, e.Err)
+}
+
+func (e *StructuredError) Unwrap() error {
+	return e.Err
+}
+
+// CreateStructured asks the model for a JSON value of type T and parses it. The schema is
+// derived from T (see JSONSchema) and sent as a strict json_schema ResponseFormat; optional
+// fields are sent as nullable, since strict mode requires every property. Non-object types
+// are wrapped in a {"value": ...} object and unwrapped on return. Types whose schema has an
+// object without declared properties, such as recursive types, are rejected with a
+// ValidationError before any request is sent.
+//
+// The reply is checked against the schema (required properties, unknown properties, enums),
+// decoded into T and, if T implements Validator, validated. On failure the reply and the
+// error are appended to the conversation and the model is asked again, up to
+// WithStructuredRepairs times. Provider errors are returned as-is. req is not modified; the
+// returned ChatResponse is the last one, with Usage summed over all attempts.
+func CreateStructured[T any](ctx context.Context, p ChatProvider, req *ChatRequest, opts ...StructuredOption) (T, *ChatResponse, error) {
+	var zero T
+	if req == nil {
+		return zero, nil, &ValidationError{Field: "request", Message: "cannot be nil"}
+	}
+	cfg := structuredConfig{repairs: DefaultStructuredRepairs}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.name == "" {
+		cfg.name = schemaName(reflect.TypeFor[T]())
+	}
+
+	schema := SchemaFor[T]()
+	wrapped := schema["type"] != "object"
+	if wrapped {
+		schema = map[string]any{
+			"type":                 "object",
+			"properties":           map[string]any{"value": schema},
+			"required":             []string{"value"},
+			"additionalProperties": false,
+		}
+	}
+	if path, ok := openObject(schema, "$"); ok {
+		return zero, nil, &ValidationError{
+			Field:   "schema",
+			Message: fmt.Sprintf("%s: %s is an object without declared properties, which strict structured output rejects (recursive types and maps with non-string keys are not supported)", reflect.TypeFor[T](), path),
+		}
+	}
+	r := *req
+	r.Stream = false
+	r.Messages = append([]Message(nil), req.Messages...)
+	r.ResponseFormat = &ResponseFormat{
+		Type:       "json_schema",
+		JSONSchema: &JSONSchemaDef{Name: cfg.name, Strict: true, Schema: strictSchema(schema)},
+	}
+
+	var usage Usage
+	var resp *ChatResponse
+	var lastErr error
+	content := ""
+	attempts := 0
+	for attempts <= cfg.repairs {
+		var err error
+		resp, err = p.Create(ctx, &r)
+		if err != nil {
+			return zero, resp, err
+		}
+		attempts++
+		addUsage(&usage, resp.Usage)
+		if resp.Usage != nil {
+			u := usage
+			resp.Usage = &u
+		}
+		content = structuredContent(resp)
+		v, err := parseStructured[T](content, schema, wrapped)
+		if err == nil {
+			return v, resp, nil
+		}
+		lastErr = err
+		r.Messages = append(r.Messages,
+			Message{Role: "assistant", Content: content},
+			Message{Role: "user", Content: fmt.Sprintf("That reply was invalid: %v. Reply again with only JSON matching the schema.", err)},
+		)
+	}
+	return zero, resp, &StructuredError{Content: content, Attempts: attempts, Err: lastErr}
+}
+
+// structuredContent returns the reply text without a surrounding Markdown code fence, which
+// models without native JSON modes often add.
+func structuredContent(resp *ChatResponse) string {
+	if len(resp.Choices) == 0 || resp.Choices[0].Message == nil {
+		return ""
+	}
+	text := strings.TrimSpace(contentText(resp.Choices[0].Message.Content))
+	if rest, ok := strings.CutPrefix(text, "```"); ok {
+		if nl := strings.IndexByte(rest, '\n'); nl >= 0 {
+			rest = rest[nl+1:]
+		}
+		text = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(rest), "```"))
+	}
+	return text
+}
+
+func parseStructured[T any](content string, schema map[string]any, wrapped bool) (T, error) {
+	var v T
+	if content == "" {
+		return v, fmt.Errorf("empty reply")
+	}
+	var doc any
+	dec := json.NewDecoder(strings.NewReader(content))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return v, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if err := validateSchema(doc, schema, "$"); err != nil {
+		return v, err
+	}
+	raw := []byte(content)
+	if wrapped {
+		raw = nil
+		if obj, ok := doc.(map[string]any); ok {
+			raw, _ = json.Marshal(obj["value"])
+		}
+	}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return v, fmt.Errorf("cannot decode into %T: %w", v, err)
+	}
+	if val, ok := any(&v).(Validator); ok {
+		if err := val.Validate(); err != nil {
+			return v, err
+		}
+	} else if val, ok := any(v).(Validator); ok {
+		if err := val.Validate(); err != nil {
+			return v, err
+		}
+	}
+	return v, nil
+}
+
+// validateSchema checks the parts of a JSONSchema-generated schema that decoding into Go
+// types does not: required and unknown properties and enum values.
+func validateSchema(v any, schema map[string]any, path string) error {
+	if enum, ok := schema["enum"].([]any); ok && v != nil {
+		got, _ := json.Marshal(v)
+		found := false
+		for _, e := range enum {
+			want, _ := json.Marshal(e)
+			if bytes.Equal(got, want) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: %s is not one of the allowed values", path, got)
+		}
+	}
+	switch val := v.(type) {
+	case map[string]any:
+		props, _ := schema["properties"].(map[string]any)
+		required, _ := schema["required"].([]string)
+		for _, name := range required {
+			v, ok := val[name]
+			if !ok {
+				return fmt.Errorf("%s: missing required property %q", path, name)
+			}
+			if prop, _ := props[name].(map[string]any); v == nil && prop["type"] != nil {
+				return fmt.Errorf("%s: required property %q must not be null", path, name)
+			}
+		}
+		names := make([]string, 0, len(val))
+		for name := range val {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			prop, ok := props[name].(map[string]any)
+			if !ok {
+				if schema["additionalProperties"] == false {
+					return fmt.Errorf("%s: unknown property %q", path, name)
+				}
+				if extra, ok := schema["additionalProperties"].(map[string]any); ok {
+					prop = extra
+				} else {
+					continue
+				}
+			}
+			if err := validateSchema(val[name], prop, path+"."+name); err != nil {
+				return err
+			}
+		}
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, e := range val {
+				if err := validateSchema(e, items, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// openObject reports the path of the first object in schema with neither properties nor an
+// additionalProperties schema, as JSONSchema emits for recursive types.
+func openObject(schema map[string]any, path string) (string, bool) {
+	if schema["type"] == "object" {
+		_, hasProps := schema["properties"].(map[string]any)
+		_, hasExtra := schema["additionalProperties"].(map[string]any)
+		if !hasProps && !hasExtra {
+			return path, true
+		}
+	}
+	if items, ok := schema["items"].(map[string]any); ok {
+		if p, ok := openObject(items, path+"[]"); ok {
+			return p, true
+		}
+	}
+	if extra, ok := schema["additionalProperties"].(map[string]any); ok {
+		if p, ok := openObject(extra, path+".*"); ok {
+			return p, true
+		}
+	}
+	props, _ := schema["properties"].(map[string]any)
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if prop, ok := props[name].(map[string]any); ok {
+			if p, ok := openObject(prop, path+"."+name); ok {
+				return p, true
+			}
+		}
+	}
+	return "", false
+}
+
+// strictSchema returns a copy of schema suitable for strict structured output: every object
+// property is required, and properties that were optional accept null.
+func strictSchema(schema map[string]any) map[string]any {
+	out := make(map[string]any, len(schema))
+	for k, v := range schema {
+		out[k] = v
+	}
+	if items, ok := schema["items"].(map[string]any); ok {
+		out["items"] = strictSchema(items)
+	}
+	if extra, ok := schema["additionalProperties"].(map[string]any); ok {
+		out["additionalProperties"] = strictSchema(extra)
+	}
+	props, ok := schema["properties"].(map[string]any)
+	if !ok {
+		return out
+	}
+	required, _ := schema["required"].([]string)
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	strictProps := make(map[string]any, len(props))
+	allRequired := append([]string(nil), required...)
+	for _, name := range names {
+		prop, _ := props[name].(map[string]any)
+		prop = strictSchema(prop)
+		if !slices.Contains(required, name) {
+			prop = nullable(prop)
+			allRequired = append(allRequired, name)
+		}
+		strictProps[name] = prop
+	}
+	out["properties"] = strictProps
+	out["required"] = allRequired
+	return out
+}
+
+// nullable makes s also accept null.
+func nullable(s map[string]any) map[string]any {
+	t, ok := s["type"].(string)
+	if !ok {
+		return s
+	}
+	s["type"] = []any{t, "null"}
+	if enum, ok := s["enum"].([]any); ok {
+		s["enum"] = append(slices.Clip(enum), nil)
+	}
+	return s
+}
+
+var schemaNameInvalid = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// schemaName derives a JSONSchemaDef.Name from t.
+func schemaName(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	name := schemaNameInvalid.ReplaceAllString(t.Name(), "_")
+	if name == "" {
+		return "response"
+	}
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
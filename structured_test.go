@@ -0,0 +1,164 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type structuredCity struct {
+	Name       string   `json:"name"`
+	Country    string   `json:"country" jsonschema:"enum=FR|NO"`
+	Population int      `json:"population,omitempty"`
+	Tags       []string `json:"tags,omitempty" jsonschema:"enum=capital|port"`
+}
+
+func (c structuredCity) Validate() error {
+	if c.Population < 0 {
+		return errors.New("population must not be negative")
+	}
+	return nil
+}
+
+// replies returns a provider answering with contents in order, recording each request.
+func replies(contents ...string) (*MockChatProvider, *[]ChatRequest) {
+	var reqs []ChatRequest
+	return &MockChatProvider{CreateFunc: func(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+		reqs = append(reqs, *req)
+		content := contents[min(len(reqs), len(contents))-1]
+		return &ChatResponse{
+			Choices: []Choice{{Message: &Message{Role: "assistant", Content: content}}},
+			Usage:   &Usage{TotalTokens: 10},
+		}, nil
+	}}, &reqs
+}
+
+func TestCreateStructured(t *testing.T) {
+	p, reqs := replies(`{"name":"Paris","country":"FR","population":2100000,"tags":["capital"]}`)
+	req := &ChatRequest{Model: "m", Messages: []Message{{Role: "user", Content: "Capital of France?"}}}
+	city, resp, err := CreateStructured[structuredCity](context.Background(), p, req)
+	if err != nil {
+		t.Fatalf("CreateStructured: %v", err)
+	}
+	if city.Name != "Paris" || city.Country != "FR" || city.Population != 2100000 || len(city.Tags) != 1 || resp == nil {
+		t.Errorf("city = %+v", city)
+	}
+	if req.ResponseFormat != nil {
+		t.Error("request should not be modified")
+	}
+
+	rf := (*reqs)[0].ResponseFormat
+	if rf == nil || rf.Type != "json_schema" || rf.JSONSchema.Name != "structuredCity" || !rf.JSONSchema.Strict {
+		t.Fatalf("response format = %+v", rf)
+	}
+	schema := rf.JSONSchema.Schema
+	if got := schema["required"]; !reflect.DeepEqual(got, []string{"name", "country", "population", "tags"}) {
+		t.Errorf("strict required = %v", got)
+	}
+	props := schema["properties"].(map[string]any)
+	if got := props["population"].(map[string]any)["type"]; !reflect.DeepEqual(got, []any{"integer", "null"}) {
+		t.Errorf("optional property type = %v", got)
+	}
+	if got := props["name"].(map[string]any)["type"]; got != "string" {
+		t.Errorf("required property type = %v", got)
+	}
+}
+
+func TestCreateStructured_Repairs(t *testing.T) {
+	p, reqs := replies(
+		`not json`,
+		`{"name":"Oslo"}`,
+		`{"name":"Oslo","country":"SE"}`,
+		`{"name":"Oslo","country":"NO","population":-1}`,
+		"```json\n{\"name\":\"Oslo\",\"country\":\"NO\"}\n```",
+	)
+	req := &ChatRequest{Model: "m", Messages: []Message{{Role: "user", Content: "Capital of Norway?"}}}
+	city, resp, err := CreateStructured[structuredCity](context.Background(), p, req, WithStructuredRepairs(4))
+	if err != nil {
+		t.Fatalf("CreateStructured: %v", err)
+	}
+	if city.Name != "Oslo" || city.Country != "NO" {
+		t.Errorf("city = %+v", city)
+	}
+	if resp.Usage.TotalTokens != 50 {
+		t.Errorf("usage = %+v, want summed over 5 attempts", resp.Usage)
+	}
+	if len(*reqs) != 5 {
+		t.Fatalf("attempts = %d", len(*reqs))
+	}
+	last := (*reqs)[4].Messages
+	if len(last) != 9 || last[1].Content != "not json" || last[1].Role != "assistant" {
+		t.Fatalf("repair transcript = %+v", last)
+	}
+	for i, want := range []string{"invalid JSON", `missing required property "country"`, "not one of the allowed values", "population must not be negative"} {
+		if msg := last[2+2*i].Content.(string); !strings.Contains(msg, want) {
+			t.Errorf("repair prompt %d = %q, want it to mention %q", i, msg, want)
+		}
+	}
+}
+
+func TestCreateStructured_GivesUp(t *testing.T) {
+	p, reqs := replies(`{"name":"Paris","country":"FR","extra":1}`)
+	_, resp, err := CreateStructured[structuredCity](context.Background(), p, &ChatRequest{Model: "m", Messages: []Message{{Role: "user", Content: "hi"}}}, WithStructuredRepairs(1))
+	var serr *StructuredError
+	if !errors.As(err, &serr) || serr.Attempts != 2 || !strings.Contains(serr.Err.Error(), `unknown property "extra"`) {
+		t.Fatalf("err = %v", err)
+	}
+	if len(*reqs) != 2 || resp == nil || serr.Content == "" {
+		t.Errorf("attempts = %d, resp = %v, content = %q", len(*reqs), resp, serr.Content)
+	}
+}
+
+func TestCreateStructured_NullRequired(t *testing.T) {
+	p, _ := replies(`{"name":null,"country":"FR"}`)
+	_, _, err := CreateStructured[structuredCity](context.Background(), p, &ChatRequest{Model: "m", Messages: []Message{{Role: "user", Content: "hi"}}}, WithStructuredRepairs(0))
+	var serr *StructuredError
+	if !errors.As(err, &serr) || !strings.Contains(serr.Err.Error(), `required property "name" must not be null`) {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+type structuredTree struct {
+	Name     string           `json:"name"`
+	Children []structuredTree `json:"children"`
+}
+
+func TestCreateStructured_RecursiveType(t *testing.T) {
+	p, reqs := replies(`{"name":"root","children":[]}`)
+	_, _, err := CreateStructured[structuredTree](context.Background(), p, &ChatRequest{Model: "m", Messages: []Message{{Role: "user", Content: "hi"}}})
+	if !errors.Is(err, ErrInvalidRequest) || !strings.Contains(err.Error(), "$.children[]") {
+		t.Fatalf("err = %v, want a schema ValidationError", err)
+	}
+	if len(*reqs) != 0 {
+		t.Errorf("attempts = %d, want none", len(*reqs))
+	}
+}
+
+func TestCreateStructured_NonObject(t *testing.T) {
+	p, reqs := replies(`{"value":["a","b"]}`)
+	got, _, err := CreateStructured[[]string](context.Background(), p, &ChatRequest{Model: "m", Messages: []Message{{Role: "user", Content: "list"}}})
+	if err != nil {
+		t.Fatalf("CreateStructured: %v", err)
+	}
+	if !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("got %v", got)
+	}
+	rf := (*reqs)[0].ResponseFormat.JSONSchema
+	if rf.Name != "response" || rf.Schema["type"] != "object" {
+		t.Errorf("schema = %+v", rf)
+	}
+}
+
+func TestCreateStructured_ProviderError(t *testing.T) {
+	p := &MockChatProvider{CreateFunc: func(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+		return nil, errTransient
+	}}
+	if _, _, err := CreateStructured[structuredCity](context.Background(), p, &ChatRequest{Model: "m", Messages: []Message{{Role: "user", Content: "hi"}}}); !errors.Is(err, errTransient) {
+		t.Errorf("err = %v", err)
+	}
+	if _, _, err := CreateStructured[structuredCity](context.Background(), p, nil); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("nil request err = %v", err)
+	}
+}
@@ -0,0 +1,171 @@
+package llm
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultTeeBuffer is the number of chunks Tee buffers per consumer.
+const DefaultTeeBuffer = 64
+
+// Tee splits r into n StreamReaders that each receive every chunk and the final error,
+// buffering up to DefaultTeeBuffer chunks per consumer. See TeeBuffered.
+func Tee(r StreamReader, n int) []StreamReader {
+	return TeeBuffered(r, n, DefaultTeeBuffer)
+}
+
+// TeeBuffered splits r into n StreamReaders, buffering up to size chunks per consumer.
+// When a consumer's buffer is full, reading from r pauses until it catches up, so memory
+// stays bounded and the slowest active consumer sets the pace.
+//
+// Closing a consumer detaches it: it no longer receives chunks or holds back the others.
+// r is closed once every consumer has been closed; if a read from r is pending at that
+// point, r is closed when it returns. Chunks are shared between consumers
+// and must not be modified.
+func TeeBuffered(r StreamReader, n, size int) []StreamReader {
+	if n <= 0 {
+		return nil
+	}
+	if size < 0 {
+		size = 0
+	}
+	t := &tee{r: r}
+	t.open.Store(int32(n))
+	out := make([]StreamReader, n)
+	t.consumers = make([]*teeReader, n)
+	for i := range out {
+		c := &teeReader{t: t, ch: make(chan teeItem, size), done: make(chan struct{})}
+		t.consumers[i] = c
+		out[i] = c
+	}
+	go t.pump()
+	return out
+}
+
+type teeItem struct {
+	chunk *StreamChunk
+	err   error
+}
+
+type tee struct {
+	r         StreamReader
+	consumers []*teeReader
+	open      atomic.Int32
+
+	mu      sync.Mutex
+	stopped bool // pump no longer calls r.Next
+	closed  bool
+}
+
+func (t *tee) pump() {
+	defer func() {
+		for _, c := range t.consumers {
+			close(c.ch)
+		}
+	}()
+	for {
+		chunk, err := t.r.Next()
+		stop := t.stopReading(err != nil)
+		if chunk != nil {
+			t.send(teeItem{chunk: chunk})
+		}
+		if err != nil {
+			t.send(teeItem{err: err})
+			return
+		}
+		if stop {
+			return
+		}
+	}
+}
+
+// send delivers item to every consumer that has not been closed.
+func (t *tee) send(item teeItem) {
+	for _, c := range t.consumers {
+		select {
+		case c.ch <- item:
+		case <-c.done:
+		}
+	}
+}
+
+// stopReading is called by pump after each r.Next. It reports whether pump should stop,
+// which it does at the end of the stream or once every consumer has been closed, and
+// then closes r if every consumer has been closed. r is only closed while no Next is
+// pending, since stream readers need not support Close concurrently with Next.
+func (t *tee) stopReading(end bool) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if end || t.open.Load() == 0 {
+		t.stopped = true
+		t.closeUpstreamLocked()
+	}
+	return t.stopped
+}
+
+// consumerClosed is called when a consumer detaches. r is closed right away if pump has
+// stopped reading; otherwise pump closes it after its pending Next returns.
+func (t *tee) consumerClosed() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.stopped {
+		return nil
+	}
+	return t.closeUpstreamLocked()
+}
+
+func (t *tee) closeUpstreamLocked() error {
+	if t.closed || t.open.Load() > 0 {
+		return nil
+	}
+	t.closed = true
+	return t.r.Close()
+}
+
+// teeReader is one consumer of a Tee.
+type teeReader struct {
+	t         *tee
+	ch        chan teeItem
+	done      chan struct{}
+	err       error
+	closeOnce sync.Once
+}
+
+func (c *teeReader) Next() (*StreamChunk, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	// Check done first: select picks randomly when buffered chunks are also ready.
+	select {
+	case <-c.done:
+		return nil, io.ErrClosedPipe
+	default:
+	}
+	select {
+	case <-c.done:
+		return nil, io.ErrClosedPipe
+	case item, ok := <-c.ch:
+		if !ok {
+			c.err = io.EOF
+			return nil, c.err
+		}
+		if item.err != nil {
+			c.err = item.err
+			return nil, item.err
+		}
+		return item.chunk, nil
+	}
+}
+
+// Close detaches the consumer; it may be called concurrently with Next.
+func (c *teeReader) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.done)
+		if c.t.open.Add(-1) == 0 {
+			err = c.t.consumerClosed()
+		}
+	})
+	return err
+}
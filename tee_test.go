@@ -0,0 +1,123 @@
+package llm
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTee(t *testing.T) {
+	upstream := &MockStreamReader{Chunks: []*StreamChunk{textChunk("a"), textChunk("b"), textChunk("c")}}
+	readers := Tee(upstream, 3)
+	var wg sync.WaitGroup
+	texts := make([]string, len(readers))
+	for i, r := range readers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer r.Close()
+			for delta, err := range TextDeltas(r) {
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				texts[i] += delta
+			}
+		}()
+	}
+	wg.Wait()
+	for i, text := range texts {
+		if text != "abc" {
+			t.Errorf("consumer %d text = %q", i, text)
+		}
+	}
+	if !upstream.Closed {
+		t.Error("upstream should be closed once every consumer closed")
+	}
+}
+
+func TestTee_ConsumerStopsEarly(t *testing.T) {
+	chunks := make([]*StreamChunk, 10)
+	for i := range chunks {
+		chunks[i] = textChunk("x")
+	}
+	upstream := &MockStreamReader{Chunks: chunks, Err: errors.New("boom")}
+	readers := TeeBuffered(upstream, 2, 1)
+	quitter, reader := readers[0], readers[1]
+	if _, err := quitter.Next(); err != nil {
+		t.Fatal(err)
+	}
+	quitter.Close()
+	if _, err := quitter.Next(); !errors.Is(err, io.ErrClosedPipe) {
+		t.Errorf("Next after Close = %v, want io.ErrClosedPipe", err)
+	}
+
+	n := 0
+	var err error
+	for {
+		if _, err = reader.Next(); err != nil {
+			break
+		}
+		n++
+	}
+	if n != 10 || err == nil || err.Error() != "boom" {
+		t.Errorf("read %d chunks, err = %v; want 10 and boom", n, err)
+	}
+	if upstream.Closed {
+		t.Error("upstream should stay open while a consumer is open")
+	}
+	reader.Close()
+	if !upstream.Closed {
+		t.Error("upstream should be closed")
+	}
+}
+
+// gatedStream returns a chunk from Next each time release is signaled.
+type gatedStream struct {
+	release chan struct{}
+	closed  chan struct{}
+}
+
+func (s *gatedStream) Next() (*StreamChunk, error) {
+	<-s.release
+	return textChunk("x"), nil
+}
+
+func (s *gatedStream) Close() error {
+	close(s.closed)
+	return nil
+}
+
+func TestTee_CloseUnblocksNext(t *testing.T) {
+	upstream := &gatedStream{release: make(chan struct{}), closed: make(chan struct{})}
+	readers := Tee(upstream, 2)
+	done := make(chan error, 1)
+	go func() {
+		_, err := readers[0].Next()
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	readers[0].Close()
+	select {
+	case err := <-done:
+		if !errors.Is(err, io.ErrClosedPipe) {
+			t.Errorf("err = %v, want io.ErrClosedPipe", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Close did not unblock Next")
+	}
+	readers[1].Close()
+	select {
+	case <-upstream.closed:
+		t.Fatal("upstream closed while its Next was pending")
+	default:
+	}
+	upstream.release <- struct{}{}
+	select {
+	case <-upstream.closed:
+	case <-time.After(time.Second):
+		t.Fatal("upstream not closed after its Next returned")
+	}
+}
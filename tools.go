@@ -0,0 +1,178 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrUnknownTool is returned when a tool call names a tool that is not registered.
+type ErrUnknownTool struct {
+	Name string
+}
+
+func (e *ErrUnknownTool) Error() string {
+	return fmt.Sprintf("llm: unknown tool %q", e.Name)
+}
+
+// Is supports errors.Is for ErrUnknownTool.
+func (e *ErrUnknownTool) Is(target error) bool {
+	t, ok := target.(*ErrUnknownTool)
+	return ok && (t == nil || t.Name == "" || t.Name == e.Name)
+}
+
+// ToolRegistry holds Go functions exposed to models as tools. Register functions with
+// RegisterTool, send Tools() with the ChatRequest, and answer the model's ToolCalls with Call.
+// A ToolRegistry is safe for concurrent use.
+type ToolRegistry struct {
+	mu    sync.RWMutex
+	tools map[string]*registeredTool
+}
+
+type registeredTool struct {
+	def  FunctionDef
+	call func(ctx context.Context, arguments string) (string, error)
+}
+
+// NewToolRegistry returns an empty ToolRegistry.
+func NewToolRegistry() *ToolRegistry {
+	return &ToolRegistry{tools: make(map[string]*registeredTool)}
+}
+
+var toolNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// RegisterTool adds fn to r as the tool name. The JSON Schema of A (see JSONSchema) becomes
+// FunctionDef.Parameters; A should be a struct. When the model calls the tool, its arguments
+// are decoded into A and fn's result is sent back as the tool message content: strings as-is,
+// other values JSON-encoded. Registering an existing name replaces it.
+func RegisterTool[A, R any](r *ToolRegistry, name, description string, fn func(context.Context, A) (R, error)) error {
+	if fn == nil {
+		return &ValidationError{Field: "fn", Message: "cannot be nil"}
+	}
+	argType := reflect.TypeFor[A]()
+	for argType.Kind() == reflect.Pointer {
+		argType = argType.Elem()
+	}
+	if argType.Kind() != reflect.Struct {
+		return &ValidationError{Field: "arguments", Message: fmt.Sprintf("must be a struct, got %s", argType)}
+	}
+	def := FunctionDef{Name: name, Description: description, Parameters: SchemaFor[A]()}
+	return r.RegisterFunc(def, func(ctx context.Context, arguments string) (string, error) {
+		var args A
+		if strings.TrimSpace(arguments) == "" {
+			arguments = "{}"
+		}
+		if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+			return "", &ValidationError{Field: "arguments", Message: fmt.Sprintf("invalid JSON for tool %s: %v", name, err)}
+		}
+		result, err := fn(ctx, args)
+		if err != nil {
+			return "", err
+		}
+		if s, ok := any(result).(string); ok {
+			return s, nil
+		}
+		out, err := json.Marshal(result)
+		if err != nil {
+			return "", err
+		}
+		return string(out), nil
+	})
+}
+
+// RegisterFunc adds an untyped tool: fn receives the raw JSON arguments and returns the tool
+// message content. It is meant for tools whose schema is not a Go type, such as tools proxied
+// from another server. Registering an existing name replaces it.
+func (r *ToolRegistry) RegisterFunc(def FunctionDef, fn func(ctx context.Context, arguments string) (string, error)) error {
+	if !toolNamePattern.MatchString(def.Name) {
+		return &ValidationError{Field: "name", Message: "must be 1-64 letters, digits, underscores or dashes"}
+	}
+	if fn == nil {
+		return &ValidationError{Field: "fn", Message: "cannot be nil"}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tools[def.Name] = &registeredTool{def: def, call: fn}
+	return nil
+}
+
+// Tools returns the registered tools sorted by name, ready for ChatRequest.Tools.
+func (r *ToolRegistry) Tools() []Tool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]Tool, 0, len(r.tools))
+	for _, t := range r.tools {
+		out = append(out, Tool{Type: "function", Function: t.def})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Function.Name < out[j].Function.Name })
+	return out
+}
+
+// Has reports whether a tool named name is registered.
+func (r *ToolRegistry) Has(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.tools[name]
+	return ok
+}
+
+// Call runs the tool named by call and returns the tool message answering it. If the tool is
+// unknown, its arguments do not decode, or it fails or panics, the message content reports
+// the error to the model and the error is also returned.
+func (r *ToolRegistry) Call(ctx context.Context, call ToolCall) (Message, error) {
+	msg := Message{Role: "tool", ToolCallID: call.ID, Name: call.Function.Name}
+	r.mu.RLock()
+	tool, ok := r.tools[call.Function.Name]
+	r.mu.RUnlock()
+	if !ok {
+		err := &ErrUnknownTool{Name: call.Function.Name}
+		msg.Content = "error: " + err.Error()
+		return msg, err
+	}
+	out, err := tool.run(ctx, call.Function.Arguments)
+	if err != nil {
+		msg.Content = "error: " + err.Error()
+		return msg, err
+	}
+	msg.Content = out
+	return msg, nil
+}
+
+// run calls the tool, turning a panic into an error so that a faulty handler cannot crash
+// the process, which matters most on the goroutines of CallAll and parallel agent steps.
+func (t *registeredTool) run(ctx context.Context, arguments string) (out string, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("llm: tool %s panicked: %v", t.def.Name, p)
+		}
+	}()
+	return t.call(ctx, arguments)
+}
+
+// CallAll runs calls concurrently and returns their tool messages in order, along with the
+// errors of failed calls. Failed calls still produce a message describing the error.
+func (r *ToolRegistry) CallAll(ctx context.Context, calls []ToolCall) ([]Message, []error) {
+	msgs := make([]Message, len(calls))
+	errs := make([]error, len(calls))
+	var wg sync.WaitGroup
+	for i, call := range calls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			msgs[i], errs[i] = r.Call(ctx, call)
+		}()
+	}
+	wg.Wait()
+	var failed []error
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err)
+		}
+	}
+	return msgs, failed
+}
@@ -0,0 +1,142 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type weatherArgs struct {
+	City string `json:"city" jsonschema:"description=City name"`
+	Unit string `json:"unit,omitempty" jsonschema:"enum=celsius|fahrenheit"`
+}
+
+type weatherResult struct {
+	TempC float64 `json:"temp_c"`
+}
+
+func newWeatherRegistry(t *testing.T) *ToolRegistry {
+	t.Helper()
+	r := NewToolRegistry()
+	err := RegisterTool(r, "get_weather", "Current weather", func(ctx context.Context, a weatherArgs) (weatherResult, error) {
+		if a.City == "" {
+			return weatherResult{}, errors.New("city is required")
+		}
+		return weatherResult{TempC: 21.5}, nil
+	})
+	if err != nil {
+		t.Fatalf("RegisterTool: %v", err)
+	}
+	err = RegisterTool(r, "echo", "Echo text", func(ctx context.Context, a struct {
+		Text string `json:"text"`
+	}) (string, error) {
+		return a.Text, nil
+	})
+	if err != nil {
+		t.Fatalf("RegisterTool: %v", err)
+	}
+	return r
+}
+
+func TestToolRegistry_Tools(t *testing.T) {
+	tools := newWeatherRegistry(t).Tools()
+	if len(tools) != 2 || tools[0].Function.Name != "echo" || tools[1].Function.Name != "get_weather" {
+		t.Fatalf("tools = %+v", tools)
+	}
+	weather := tools[1]
+	if weather.Type != "function" || weather.Function.Description != "Current weather" {
+		t.Errorf("tool = %+v", weather)
+	}
+	params := weather.Function.Parameters
+	props := params["properties"].(map[string]any)
+	if props["city"].(map[string]any)["description"] != "City name" {
+		t.Errorf("parameters = %v", params)
+	}
+}
+
+func TestToolRegistry_Call(t *testing.T) {
+	r := newWeatherRegistry(t)
+	ctx := context.Background()
+
+	msg, err := r.Call(ctx, ToolCall{ID: "call_1", Function: FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`}})
+	if err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	if msg.Role != "tool" || msg.ToolCallID != "call_1" || msg.Content != `{"temp_c":21.5}` {
+		t.Errorf("msg = %+v", msg)
+	}
+
+	msg, err = r.Call(ctx, ToolCall{ID: "call_2", Function: FunctionCall{Name: "echo", Arguments: `{"text":"hi"}`}})
+	if err != nil || msg.Content != "hi" {
+		t.Errorf("echo = %+v, %v", msg, err)
+	}
+
+	// Empty arguments decode as {} and reach the function.
+	msg, err = r.Call(ctx, ToolCall{ID: "call_3", Function: FunctionCall{Name: "get_weather"}})
+	if err == nil || msg.ToolCallID != "call_3" || !strings.Contains(msg.Content.(string), "city is required") {
+		t.Errorf("tool error = %+v, %v", msg, err)
+	}
+
+	var verr *ValidationError
+	if _, err := r.Call(ctx, ToolCall{Function: FunctionCall{Name: "get_weather", Arguments: `{bad`}}); !errors.As(err, &verr) {
+		t.Errorf("invalid JSON err = %v", err)
+	}
+
+	msg, err = r.Call(ctx, ToolCall{ID: "call_4", Function: FunctionCall{Name: "missing"}})
+	if !errors.Is(err, &ErrUnknownTool{}) || msg.ToolCallID != "call_4" {
+		t.Errorf("unknown tool = %+v, %v", msg, err)
+	}
+}
+
+func TestToolRegistry_CallAll(t *testing.T) {
+	r := newWeatherRegistry(t)
+	msgs, errs := r.CallAll(context.Background(), []ToolCall{
+		{ID: "a", Function: FunctionCall{Name: "echo", Arguments: `{"text":"one"}`}},
+		{ID: "b", Function: FunctionCall{Name: "missing"}},
+		{ID: "c", Function: FunctionCall{Name: "echo", Arguments: `{"text":"three"}`}},
+	})
+	if len(msgs) != 3 || msgs[0].Content != "one" || msgs[1].ToolCallID != "b" || msgs[2].Content != "three" {
+		t.Errorf("msgs = %+v", msgs)
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], &ErrUnknownTool{Name: "missing"}) {
+		t.Errorf("errs = %v", errs)
+	}
+}
+
+func TestToolRegistry_CallPanic(t *testing.T) {
+	r := newWeatherRegistry(t)
+	err := r.RegisterFunc(FunctionDef{Name: "boom"}, func(context.Context, string) (string, error) {
+		panic("nil map")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgs, errs := r.CallAll(context.Background(), []ToolCall{
+		{ID: "a", Function: FunctionCall{Name: "boom"}},
+		{ID: "b", Function: FunctionCall{Name: "echo", Arguments: `{"text":"ok"}`}},
+	})
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "tool boom panicked: nil map") {
+		t.Errorf("errs = %v", errs)
+	}
+	if !strings.HasPrefix(msgs[0].Content.(string), "error: ") || msgs[1].Content != "ok" {
+		t.Errorf("msgs = %+v", msgs)
+	}
+}
+
+func TestRegisterTool_Invalid(t *testing.T) {
+	r := NewToolRegistry()
+	noop := func(ctx context.Context, a weatherArgs) (string, error) { return "", nil }
+	if err := RegisterTool(r, "bad name", "", noop); err == nil {
+		t.Error("expected error for invalid name")
+	}
+	if err := RegisterTool[weatherArgs, string](r, "nil_fn", "", nil); err == nil {
+		t.Error("expected error for nil fn")
+	}
+	if err := RegisterTool(r, "scalar", "", func(ctx context.Context, a string) (string, error) { return a, nil }); err == nil {
+		t.Error("expected error for non-struct arguments")
+	}
+	if r.Has("bad name") || r.Has("nil_fn") || r.Has("scalar") {
+		t.Error("invalid tools should not be registered")
+	}
+}
@@ -112,6 +112,9 @@ type ChatResponse struct {
 	Model   string   `json:"model"`
 	Choices []Choice `json:"choices"`
 	Usage   *Usage   `json:"usage,omitempty"`
+
+	// Fallback is set by FallbackChatProvider to the target that served the request.
+	Fallback *FallbackInfo `json:"-"`
 }
 
 // ChoiceError represents provider error details in a choice (e.g. streaming partial failure).
@@ -145,6 +148,9 @@ type StreamChunk struct {
 	Model   string   `json:"model"`
 	Choices []Choice `json:"choices"`
 	Usage   *Usage   `json:"usage,omitempty"`
+
+	// Fallback is set by FallbackChatProvider to the target that served the request.
+	Fallback *FallbackInfo `json:"-"`
 }
 
 // EmbeddingRequest is the request for creating embeddings.
@@ -0,0 +1,47 @@
+package llm
+
+// ValidateChatRequest runs the checks every provider applies before sending req: it must be
+// non-nil with a model and at least one message. Failures match ErrInvalidRequest.
+func ValidateChatRequest(req *ChatRequest) error {
+	return validateChatRequest(req)
+}
+
+func validateChatRequest(req *ChatRequest) error {
+	if req == nil {
+		return &ValidationError{Field: "request", Message: "cannot be nil"}
+	}
+	if req.Model == "" {
+		return &ValidationError{Field: "model", Message: "cannot be empty"}
+	}
+	if len(req.Messages) == 0 {
+		return &ValidationError{Field: "messages", Message: "cannot be empty"}
+	}
+	return nil
+}
+
+func validateEmbeddingRequest(req *EmbeddingRequest) error {
+	if req == nil {
+		return &ValidationError{Field: "request", Message: "cannot be nil"}
+	}
+	if req.Model == "" {
+		return &ValidationError{Field: "model", Message: "cannot be empty"}
+	}
+	if req.Input == nil {
+		return &ValidationError{Field: "input", Message: "cannot be nil"}
+	}
+	switch v := req.Input.(type) {
+	case string:
+		if v == "" {
+			return &ValidationError{Field: "input", Message: "cannot be empty string"}
+		}
+	case []string:
+		if len(v) == 0 {
+			return &ValidationError{Field: "input", Message: "cannot be empty slice"}
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			return &ValidationError{Field: "input", Message: "cannot be empty slice"}
+		}
+	}
+	return nil
+}
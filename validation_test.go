@@ -32,6 +32,15 @@ func TestValidateChatRequest(t *testing.T) {
 	}
 }
 
+func TestValidateChatRequest_Exported(t *testing.T) {
+	if err := ValidateChatRequest(&ChatRequest{Model: "m"}); !errors.Is(err, &ValidationError{Field: "messages", Message: "cannot be empty"}) {
+		t.Errorf("ValidateChatRequest() error = %v, want empty messages", err)
+	}
+	if err := ValidateChatRequest(&ChatRequest{Model: "m", Messages: []Message{{Role: "user", Content: "hi"}}}); err != nil {
+		t.Errorf("ValidateChatRequest() error = %v", err)
+	}
+}
+
 func TestValidateEmbeddingRequest(t *testing.T) {
 	tests := []struct {
 		name    string